@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gotify/plugin-api"
+)
+
+// actionMuteDuration is how long the mute action of a notification mutes its channel.
+const actionMuteDuration = time.Hour
+
+// actionLinkTTL is how long the action links of a notification work.
+const actionLinkTTL = 24 * time.Hour
+
+// addActions makes a notification open the conversation in the Slack app
+// when clicked, unless it already links to a thread, and adds a link muting
+// the channel for an hour. The mute link requires PublicURL. It is kept in
+// the slack::message extras and only added to the text of notifications
+// sent to gotify, so it does not reach the mirror or MQTT.
+func (c *Plugin) addActions(msg *plugin.Message, team, channel string) {
+	c.mu.Lock()
+	enabled := c.config.Actions
+	c.mu.Unlock()
+	if !enabled {
+		return
+	}
+	if ns, _ := msg.Extras["client::notification"].(map[string]interface{}); ns["click"] == nil {
+		deepLink := "slack://channel?" + url.Values{"team": {team}, "id": {channel}}.Encode()
+		setExtra(msg, "client::notification", "click", map[string]string{"url": deepLink})
+	}
+	expires := strconv.FormatInt(time.Now().Add(actionLinkTTL).Unix(), 10)
+	sig, err := c.signAction(channel, expires)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	if u := c.publicURL("mute", url.Values{"channel": {channel}, "expires": {expires}, "sig": {sig}}); u != "" {
+		setExtra(msg, "slack::message", "mute", u)
+	}
+}
+
+// withActionLinks returns msg with the mute link added by addActions appended to its text.
+func (c *Plugin) withActionLinks(msg plugin.Message) plugin.Message {
+	ns, _ := msg.Extras["slack::message"].(map[string]interface{})
+	if u, _ := ns["mute"].(string); u != "" {
+		msg.Message += "\n" + tr(c.locale(), "Mute for an hour") + ": " + u
+	}
+	return msg
+}
+
+// publicURL returns the URL of a webhook route as reachable from clients,
+// or an empty string if PublicURL is not configured.
+func (c *Plugin) publicURL(route string, query url.Values) string {
+	c.mu.Lock()
+	base := c.config.PublicURL
+	c.mu.Unlock()
+	if base == "" || c.basePath == "" {
+		return ""
+	}
+	u := strings.TrimSuffix(base, "/") + strings.TrimSuffix(c.basePath, "/") + "/" + route
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// signingKey returns the key signing action links, generating and storing
+// it on first use so that links keep working after a restart.
+func (c *Plugin) signingKey() ([]byte, error) {
+	c.mu.Lock()
+	key := c.actionKey
+	c.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+	var stored string
+	err := c.updateState(func(s *storedState) {
+		if s.ActionKey == "" {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				return
+			}
+			s.ActionKey = hex.EncodeToString(b)
+		}
+		stored = s.ActionKey
+	})
+	if err != nil {
+		return nil, err
+	}
+	key, err = hex.DecodeString(stored)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("no key to sign action links")
+	}
+	c.mu.Lock()
+	c.actionKey = key
+	c.mu.Unlock()
+	return key, nil
+}
+
+// signAction signs the mute action of channel expiring at the Unix time expires.
+func (c *Plugin) signAction(channel, expires string) (string, error) {
+	key, err := c.signingKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(channel + "\x00" + expires))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// handleMute mutes the channel given in the query for an hour, given a
+// valid signature of a link that has not expired.
+func (c *Plugin) handleMute(ctx *gin.Context) {
+	channel, expires := ctx.Query("channel"), ctx.Query("expires")
+	if channel == "" {
+		ctx.String(http.StatusBadRequest, "missing channel")
+		return
+	}
+	sig, err := c.signAction(channel, expires)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !hmac.Equal([]byte(sig), []byte(ctx.Query("sig"))) {
+		ctx.String(http.StatusForbidden, "invalid signature")
+		return
+	}
+	if t, err := strconv.ParseInt(expires, 10, 64); err != nil || time.Now().Unix() > t {
+		ctx.String(http.StatusForbidden, "the link has expired")
+		return
+	}
+	until := time.Now().Add(actionMuteDuration)
+	c.mu.Lock()
+	if c.muted == nil {
+		c.muted = make(map[string]time.Time)
+	}
+	c.muted[channel] = until
+	c.mu.Unlock()
+	ctx.String(http.StatusOK, fmt.Sprintf(tr(c.locale(), "Muted the channel until %s."), until.Format("15:04")))
+}
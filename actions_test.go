@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddActions(t *testing.T) {
+	c := &Plugin{basePath: "/plugin/1/custom/token/", config: &Config{Actions: true, PublicURL: "https://gotify.example.com/"}}
+	msg := plugin.Message{Message: "hi"}
+	c.addActions(&msg, "T1", "C1")
+	assert.Equal(t, "hi", msg.Message)
+	assert.Equal(t, map[string]string{"url": "slack://channel?id=C1&team=T1"},
+		msg.Extras["client::notification"].(map[string]interface{})["click"])
+	sent := c.withActionLinks(msg)
+	assert.True(t, strings.HasPrefix(sent.Message, "hi\nMute for an hour: https://gotify.example.com/plugin/1/custom/token/mute?channel=C1&expires="))
+	assert.Contains(t, sent.Message, "&sig=")
+
+	msg = plugin.Message{}
+	setExtra(&msg, "client::notification", "click", map[string]string{"url": "https://example.slack.com/archives/C1/p1"})
+	c.addActions(&msg, "T1", "C1")
+	assert.Equal(t, map[string]string{"url": "https://example.slack.com/archives/C1/p1"},
+		msg.Extras["client::notification"].(map[string]interface{})["click"])
+}
+
+func TestHandleMute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.SetStorageHandler(&memoryStorage{})
+	r := gin.New()
+	c.RegisterWebhook("/", r.Group("/"))
+	mute := func(channel string, expires time.Time, signed string) int {
+		exp := strconv.FormatInt(expires.Unix(), 10)
+		sig, err := c.signAction(signed, exp)
+		assert.NoError(t, err)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/mute?"+url.Values{"channel": {channel}, "expires": {exp}, "sig": {sig}}.Encode(), nil))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, mute("C2", time.Now().Add(time.Hour), "C1"))
+	assert.Equal(t, http.StatusForbidden, mute("C1", time.Now().Add(-time.Minute), "C1"))
+	assert.Empty(t, c.muted)
+	assert.Equal(t, http.StatusOK, mute("C1", time.Now().Add(time.Hour), "C1"))
+	assert.Contains(t, c.muted, "C1")
+
+	// The key is kept in the storage, so links survive a restart.
+	key := c.actionKey
+	c.actionKey = nil
+	k, err := c.signingKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key, k)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// activityHours is the period covered by the activity overview.
+const activityHours = 7 * 24
+
+// activity counts the messages forwarded from one conversation in hourly buckets.
+type activity struct {
+	counts [activityHours]int
+	hours  [activityHours]int64
+	last   time.Time
+}
+
+func (a *activity) add(t time.Time) {
+	hour := t.Unix() / 3600
+	i := hour % activityHours
+	if a.hours[i] != hour {
+		a.hours[i] = hour
+		a.counts[i] = 0
+	}
+	a.counts[i]++
+	a.last = t
+}
+
+// since returns the number of messages forwarded in the last n hours before now.
+func (a *activity) since(now time.Time, n int64) int {
+	hour := now.Unix() / 3600
+	total := 0
+	for i := range a.counts {
+		if a.hours[i] > hour-n && a.hours[i] <= hour {
+			total += a.counts[i]
+		}
+	}
+	return total
+}
+
+// conversationLabel names a conversation for statistics.
+func conversationLabel(channelName, userName string, direct bool) string {
+	if direct {
+		return "@" + userName
+	}
+	return "#" + channelName
+}
+
+// activityTable renders the forwarding activity per conversation as a
+// markdown table, busiest conversation first.
+func (c *Plugin) activityTable(l string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.activity) == 0 {
+		return tr(l, "No messages yet.") + "\n"
+	}
+	now := time.Now()
+	type row struct {
+		name       string
+		day, week  int
+		lastActive time.Time
+	}
+	var rows []row
+	for name, a := range c.activity {
+		rows = append(rows, row{name, a.since(now, 24), a.since(now, activityHours), a.last})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].day != rows[j].day {
+			return rows[i].day > rows[j].day
+		}
+		return rows[i].week > rows[j].week
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | 24h | 7d | %s |\n|---|---|---|---|\n", tr(l, "Conversation"), tr(l, "Last forwarded"))
+	for _, r := range rows {
+		fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", escapeCell(r.name), r.day, r.week, r.lastActive.Format("2006-01-02 15:04"))
+	}
+	return b.String()
+}
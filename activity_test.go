@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivitySince(t *testing.T) {
+	var a activity
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	a.add(now.Add(-3 * 24 * time.Hour))
+	a.add(now.Add(-2 * time.Hour))
+	a.add(now)
+	assert.Equal(t, 2, a.since(now, 24))
+	assert.Equal(t, 3, a.since(now, activityHours))
+	assert.Equal(t, 0, a.since(now.Add(8*24*time.Hour), activityHours))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	botParsers = append(botParsers, botParser{
+		name:  "alerts",
+		bots:  []string{"pagerduty", "opsgenie", "alertmanager", "grafana"},
+		parse: parseAlert,
+	})
+}
+
+// alertStatusRe finds the status of an alert in its text.
+var alertStatusRe = regexp.MustCompile(`(?i)\b(triggered|acknowledged|resolved|firing|escalated|closed)\b`)
+
+// alertSeverities maps severities of alerting tools to priorities.
+var alertSeverities = map[string]int{
+	"p1": 10, "critical": 10, "sev1": 10,
+	"p2": 8, "high": 8, "error": 8, "sev2": 8,
+	"p3": 6, "warning": 6, "medium": 6, "sev3": 6,
+	"p4": 4, "low": 4, "sev4": 4,
+	"p5": 2, "info": 2, "sev5": 2,
+}
+
+// parseAlert parses an alert posted by PagerDuty, Opsgenie or a similar
+// bot into its title, status, severity and incident URL. The status is
+// the kind of the message. Resolved alerts get a low priority.
+func parseAlert(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage {
+	if len(msg.Attachments) == 0 {
+		return nil
+	}
+	att := msg.Attachments[0]
+	title := att.Title
+	if title == "" {
+		title = att.Fallback
+	}
+	if title == "" {
+		return nil
+	}
+	p := &parsedMessage{text: title, url: att.TitleLink}
+	haystack := []string{msg.Text, att.Pretext, att.Text, att.Title, att.Fallback}
+	for _, f := range att.Fields {
+		value := strings.ToLower(strings.TrimSpace(f.Value))
+		switch strings.ToLower(f.Title) {
+		case "severity", "priority", "urgency":
+			if prio, ok := alertSeverities[value]; ok {
+				p.priority = prio
+			}
+		case "status":
+			p.kind = value
+		}
+		if f.Title != "" && f.Value != "" && !strings.EqualFold(f.Title, "status") {
+			p.text += "\n" + f.Title + ": " + f.Value
+		}
+	}
+	if p.kind == "" {
+		for _, s := range haystack {
+			if m := alertStatusRe.FindString(s); m != "" {
+				p.kind = strings.ToLower(m)
+				break
+			}
+		}
+	}
+	if p.kind != "" {
+		p.label = "[" + strings.ToUpper(p.kind[:1]) + p.kind[1:] + "]"
+	}
+	if p.kind == "resolved" || p.kind == "closed" {
+		p.priority = 2
+	}
+	if p.url == "" {
+		for _, a := range att.Actions {
+			if a.URL != "" {
+				p.url = a.URL
+				break
+			}
+		}
+	}
+	return p
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAlert(t *testing.T) {
+	c := &Plugin{config: &Config{}}
+	pagerduty := &sender{id: "B1", name: "PagerDuty", bot: true}
+	msg := &slack.Msg{Attachments: []slack.Attachment{{
+		Title:     "Database CPU above 95%",
+		TitleLink: "https://acme.pagerduty.com/incidents/P42",
+		Text:      "Triggered by datadog",
+		Fields:    []slack.AttachmentField{{Title: "Severity", Value: "critical"}, {Title: "Service", Value: "db"}},
+	}}}
+	name, p, pc := c.parseBotMessage(pagerduty, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "alerts", name)
+		assert.Equal(t, "triggered", p.kind)
+		assert.Equal(t, "[Triggered]", p.label)
+		assert.Equal(t, "Database CPU above 95%\nSeverity: critical\nService: db", p.text)
+		assert.Equal(t, "https://acme.pagerduty.com/incidents/P42", p.url)
+		assert.Equal(t, 10, pc.priority(p, 5))
+	}
+
+	msg.Attachments[0].Fields = []slack.AttachmentField{{Title: "Status", Value: "Resolved"}}
+	_, p, pc = c.parseBotMessage(pagerduty, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "[Resolved]", p.label)
+		assert.Equal(t, 2, pc.priority(p, 5))
+	}
+
+	c.config.Parsers = map[string]ParserConfig{"alerts": {Drop: []string{"resolved"}}}
+	_, p, pc = c.parseBotMessage(pagerduty, msg, nil)
+	assert.True(t, pc.drops(p))
+
+	_, p, _ = c.parseBotMessage(&sender{id: "U1", name: "PagerDuty"}, msg, nil)
+	assert.Nil(t, p)
+	assert.Error(t, validateParsers(map[string]ParserConfig{"unknown": {}}))
+}
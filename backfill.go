@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// takeStartup returns the Startup mode if the plugin has just been enabled,
+// and "" afterwards, e.g. when reconnecting.
+func (c *Plugin) takeStartup() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := c.backfillPending
+	c.backfillPending = false
+	if !pending {
+		return ""
+	}
+	if c.config.Startup == "" {
+		return "resume"
+	}
+	return c.config.Startup
+}
+
+// startup applies the Startup mode after the plugin has been enabled. It
+// reports whether the messages missed since the persisted offsets are to
+// be caught up, which is the case for "resume" and when reconnecting. The
+// other modes skip the offsets, the backfilling ones after their backfill.
+func (c *Plugin) startup(now time.Time) bool {
+	var err error
+	switch c.takeStartup() {
+	case "live":
+	case "unread":
+		err = c.backfill(time.Time{})
+	case "recent":
+		c.mu.Lock()
+		window := c.config.BackfillWindow
+		c.mu.Unlock()
+		err = c.backfill(now.Add(-window))
+	default:
+		return true
+	}
+	if err != nil {
+		c.logln(err)
+	}
+	c.skipOffsets(now)
+	return false
+}
+
+// skipOffsets advances the offsets of all conversations to now, so that
+// no message posted before is forwarded.
+func (c *Plugin) skipOffsets(now time.Time) {
+	ts := timestamp(now)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for channel := range c.offsets {
+		c.offsets[channel] = ts
+		c.offsetsDirty = true
+	}
+}
+
+// backfill forwards the user's direct messages and mentions posted since
+// the given time or, if it is zero, those still unread, oldest first.
+func (c *Plugin) backfill(since time.Time) error {
+	channels, err := c.memberConversations()
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		oldest := timestamp(since)
+		if since.IsZero() {
+			info, err := c.slackAPI().GetConversationInfo(ch.ID, false)
+			if err != nil {
+				return err
+			}
+			if info.UnreadCountDisplay == 0 {
+				continue
+			}
+			oldest = info.LastRead
+		}
+		messages, err := c.history(ch.ID, oldest)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if !ch.IsIM && !ch.IsMpIM && !c.mentionsMe(m.Text) {
+				continue
+			}
+			m.Channel = ch.ID
+			c.handleMessage(&slack.MessageEvent{Msg: m.Msg, SubMessage: m.SubMessage}, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartup(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.backfillPending = true
+	assert.Equal(t, "resume", c.takeStartup())
+	assert.Equal(t, "", c.takeStartup())
+
+	now := time.Unix(1500000000, 0)
+	c.config.Startup = "live"
+	c.offsets = map[string]string{"C1": "1400000000.000100"}
+	assert.True(t, c.startup(now))
+	assert.Equal(t, "1400000000.000100", c.offsets["C1"])
+	c.backfillPending = true
+	assert.False(t, c.startup(now))
+	assert.Equal(t, "1500000000.000000", c.offsets["C1"])
+	assert.False(t, c.advanceOffset("C1", "1499999999.000100"))
+}
+
+func TestStartupBackfillSkipsCatchUp(t *testing.T) {
+	var history []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/users.conversations":
+			fmt.Fprint(w, `{"ok":true,"channels":[{"id":"D1","is_im":true}]}`)
+		case "/conversations.info":
+			fmt.Fprint(w, `{"ok":true,"channel":{"id":"D1","is_im":true,"unread_count_display":1,"last_read":"1499990000.000100"}}`)
+		case "/conversations.history":
+			history = append(history, r.Form.Get("channel"))
+			fmt.Fprint(w, `{"ok":true,"messages":[]}`)
+		default:
+			fmt.Fprint(w, `{"ok":false,"error":"unknown_method"}`)
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1500000000, 0)
+	for _, mode := range []string{"unread", "recent"} {
+		history = nil
+		c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+		c.config.APIURL = srv.URL
+		c.config.Startup = mode
+		c.config.BackfillWindow = time.Hour
+		c.api, _ = c.config.client()
+		c.offsets = map[string]string{"C1": "1400000000.000100"}
+		c.backfillPending = true
+		assert.False(t, c.startup(now), mode)
+		assert.Equal(t, []string{"D1"}, history, mode)
+		// Reconnecting catches up from the backfill on, not the old offsets.
+		assert.Equal(t, "1500000000.000000", c.offsets["C1"], mode)
+	}
+}
+
+func TestValidateStartup(t *testing.T) {
+	config := (&Plugin{}).DefaultConfig().(*Config)
+	config.SlackToken = "xoxp-test"
+	config.Startup = "recent"
+	assert.EqualError(t, (&Plugin{}).ValidateAndSetConfig(config), "BackfillWindow is required for the recent Startup mode")
+	config.Startup = "everything"
+	assert.Error(t, (&Plugin{}).ValidateAndSetConfig(config))
+}
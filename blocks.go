@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// block is the subset of Block Kit blocks needed to render them as text.
+// It is decoded independently of the slack library, which rejects block
+// types it does not know.
+type block struct {
+	Type     string          `json:"type"`
+	Text     json.RawMessage `json:"text"`
+	Fields   []textObject    `json:"fields"`
+	Elements []blockElement  `json:"elements"`
+	AltText  string          `json:"alt_text"`
+}
+
+type textObject struct {
+	Text string `json:"text"`
+}
+
+// blockElement is an element of a context or rich_text block.
+type blockElement struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text"`
+	URL      string         `json:"url"`
+	UserID   string         `json:"user_id"`
+	Name     string         `json:"name"`
+	Elements []blockElement `json:"elements"`
+}
+
+// renderBlocks renders Block Kit blocks as Slack formatted text.
+func renderBlocks(raw json.RawMessage) string {
+	var blocks []block
+	if len(raw) == 0 || json.Unmarshal(raw, &blocks) != nil {
+		return ""
+	}
+	var lines []string
+	for _, b := range blocks {
+		var s string
+		switch b.Type {
+		case "header", "section":
+			s = blockText(b.Text)
+			for _, f := range b.Fields {
+				s += "\n" + f.Text
+			}
+		case "context":
+			var texts []string
+			for _, e := range b.Elements {
+				if e.Text != "" {
+					texts = append(texts, e.Text)
+				}
+			}
+			s = strings.Join(texts, " ")
+		case "rich_text":
+			s = renderRichText(b.Elements)
+		case "image":
+			s = b.AltText
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blockText returns the text of a text object, which may also be a plain string.
+func blockText(raw json.RawMessage) string {
+	var obj textObject
+	if json.Unmarshal(raw, &obj) == nil {
+		return obj.Text
+	}
+	var s string
+	json.Unmarshal(raw, &s)
+	return s
+}
+
+// renderRichText renders rich_text elements back to Slack's markup.
+func renderRichText(elements []blockElement) string {
+	var b strings.Builder
+	for _, e := range elements {
+		switch e.Type {
+		case "text":
+			b.WriteString(e.Text)
+		case "link":
+			if e.Text != "" {
+				b.WriteString("<" + e.URL + "|" + e.Text + ">")
+			} else {
+				b.WriteString("<" + e.URL + ">")
+			}
+		case "user":
+			b.WriteString("<@" + e.UserID + ">")
+		case "emoji":
+			b.WriteString(":" + e.Name + ":")
+		case "rich_text_list":
+			for _, item := range e.Elements {
+				b.WriteString("• " + renderRichText(item.Elements) + "\n")
+			}
+		case "rich_text_quote":
+			b.WriteString("> " + renderRichText(e.Elements) + "\n")
+		default:
+			b.WriteString(renderRichText(e.Elements))
+			if e.Type != "rich_text_section" {
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
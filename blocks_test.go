@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderBlocks(t *testing.T) {
+	raw := []byte(`[
+		{"type":"header","text":{"type":"plain_text","text":"Meeting in 10 minutes"}},
+		{"type":"section","text":{"type":"mrkdwn","text":"*Standup*"},"fields":[{"type":"mrkdwn","text":"Room 4"}]},
+		{"type":"divider"},
+		{"type":"context","elements":[{"type":"mrkdwn","text":"Google Calendar"}]},
+		{"type":"rich_text","elements":[{"type":"rich_text_section","elements":[
+			{"type":"text","text":"hi "},{"type":"user","user_id":"U1"},{"type":"text","text":" see "},
+			{"type":"link","url":"https://example.com","text":"this"},{"type":"emoji","name":"wave"}]}]}
+	]`)
+	assert.Equal(t, "Meeting in 10 minutes\n*Standup*\nRoom 4\nGoogle Calendar\nhi <@U1> see <https://example.com|this>:wave:", renderBlocks(raw))
+	assert.Equal(t, "", renderBlocks(nil))
+}
+
+func TestRecoverMessage(t *testing.T) {
+	ev := &slack.UnmarshallingErrorEvent{ErrorObj: errors.New(`RTM Error: Could not unmarshall event "message": ` +
+		`{"type":"message","channel":"D1","user":"U1","bot_id":"B1","text":"","blocks":[{"type":"rich_text","elements":[]}]}`)}
+	msg, blocks, ok := recoverMessage(ev)
+	assert.True(t, ok)
+	assert.Equal(t, "D1", msg.Channel)
+	assert.Equal(t, "B1", msg.BotID)
+	assert.JSONEq(t, `[{"type":"rich_text","elements":[]}]`, string(blocks))
+
+	_, _, ok = recoverMessage(&slack.UnmarshallingErrorEvent{ErrorObj: errors.New(`RTM Error: Could not unmarshall event "foo": {}`)})
+	assert.False(t, ok)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nlopes/slack"
+)
+
+// breakthroughPriority is the priority of messages matching a breakthrough rule.
+const breakthroughPriority = 10
+
+// BreakthroughRule marks urgent messages, e.g. on-call pages, which are
+// delivered at the highest priority even during quiet hours, outside
+// channel windows and profiles, and regardless of the bot and Slack
+// preference filters, thread summaries and flood limits. A message matches
+// if its text matches Pattern, a regular expression, and it mentions the
+// user if Mention is set. Channels optionally restricts the rule to
+// channels as in ChannelConfig.
+type BreakthroughRule struct {
+	Pattern  string
+	Mention  bool
+	Channels []string
+}
+
+// Validate checks whether the pattern of the rule compiles.
+func (r BreakthroughRule) Validate() error {
+	if r.Pattern == "" && !r.Mention {
+		return errors.New("breakthrough rules need a Pattern or Mention")
+	}
+	if err := validateChannelPatterns(r.Channels); err != nil {
+		return err
+	}
+	if _, err := cachedRegexp(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", r.Pattern, err)
+	}
+	return nil
+}
+
+// breakthrough reports whether a message with the raw text matches one of
+// the breakthrough rules.
+func (c *Plugin) breakthrough(channel *slack.Channel, text string) bool {
+	c.mu.Lock()
+	rules := c.config.Breakthrough
+	c.mu.Unlock()
+	for _, r := range rules {
+		if len(r.Channels) != 0 && !matchChannel(r.Channels, channel) {
+			continue
+		}
+		if r.Mention && !c.mentionsMe(text) {
+			continue
+		}
+		if re, err := cachedRegexp(r.Pattern); err == nil && re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
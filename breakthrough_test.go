@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakthrough(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.QuietHours = Window{Days: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+	c.sendTestMessage()
+	assert.Empty(t, h.sent)
+
+	c.config.Breakthrough = []BreakthroughRule{{Pattern: "(?i)test message"}}
+	c.sendTestMessage()
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, 10, h.sent[0].Priority)
+	}
+}
+
+func TestBreakthroughRules(t *testing.T) {
+	c := &Plugin{uid: "U1", config: &Config{Breakthrough: []BreakthroughRule{
+		{Pattern: "(?i)urgent", Mention: true},
+		{Pattern: "PAGE", Channels: []string{"#oncall"}},
+	}}}
+	oncall := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "oncall", Conversation: slack.Conversation{ID: "C1"}}}
+	general := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general", Conversation: slack.Conversation{ID: "C2"}}}
+	assert.True(t, c.breakthrough(general, "<@U1> urgent: prod is down"))
+	assert.False(t, c.breakthrough(general, "urgent: prod is down"))
+	assert.True(t, c.breakthrough(oncall, "PAGE db-01"))
+	assert.False(t, c.breakthrough(general, "PAGE db-01"))
+	assert.Error(t, BreakthroughRule{}.Validate())
+}
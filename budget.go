@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// budgetWarning is the share of a method's rate limit at which a warning is sent.
+const budgetWarning = 0.8
+
+// tierLimits are the calls per minute Slack allows for the methods the
+// plugin uses, see https://api.slack.com/docs/rate-limits. Other methods
+// are assumed to be in tier 3.
+var tierLimits = map[string]int{
+	"rtm.connect":           1,
+	"emoji.list":            20,
+	"usergroups.list":       20,
+	"users.info":            100,
+	"bots.info":             100,
+	"chat.getPermalink":     100,
+	"auth.test":             100,
+	"conversations.info":    50,
+	"conversations.history": 50,
+	"conversations.replies": 50,
+	"users.conversations":   50,
+}
+
+// tierLimit returns the calls per minute Slack allows for a method.
+func tierLimit(method string) int {
+	if limit, ok := tierLimits[method]; ok {
+		return limit
+	}
+	return 50
+}
+
+// apiBudget counts the Web API calls per method and minute.
+type apiBudget struct {
+	mu      sync.Mutex
+	minute  time.Time
+	current map[string]int
+	last    map[string]int
+	warned  map[string]bool
+}
+
+// count counts a call of method at now. It reports whether the calls of the
+// current minute reached the warning threshold for the first time.
+// rtm.connect is never warned about: its limit of one call is reached by
+// every connect, and reconnects are already slowed down by the backoff.
+func (b *apiBudget) count(method string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if minute := now.Truncate(time.Minute); !minute.Equal(b.minute) {
+		b.last = nil
+		if minute.Sub(b.minute) == time.Minute {
+			b.last = b.current
+		}
+		b.minute, b.current = minute, make(map[string]int)
+	}
+	b.current[method]++
+	if method == "rtm.connect" || b.warned[method] || float64(b.current[method]) < budgetWarning*float64(tierLimit(method)) {
+		return false
+	}
+	if b.warned == nil {
+		b.warned = make(map[string]bool)
+	}
+	b.warned[method] = true
+	return true
+}
+
+// rates returns the calls per method of the last complete minute.
+func (b *apiBudget) rates(now time.Time) map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch now.Truncate(time.Minute).Sub(b.minute) {
+	case 0:
+		return b.last
+	case time.Minute:
+		return b.current
+	}
+	return nil
+}
+
+// countedClient is an HTTP client for the Slack Web API counting the calls.
+type countedClient struct {
+	hc *http.Client
+	c  *Plugin
+}
+
+// Do implements the HTTP client interface of the slack library.
+func (cc countedClient) Do(req *http.Request) (*http.Response, error) {
+	cc.c.countCall(path.Base(req.URL.Path))
+	return cc.hc.Do(req)
+}
+
+// countCall counts a Web API call and warns once per method when its rate
+// approaches Slack's limit.
+func (c *Plugin) countCall(method string) {
+	if !c.budget.count(method, time.Now()) {
+		return
+	}
+	c.mu.Lock()
+	l, priority := c.config.Locale, c.config.WarningPriority
+	c.mu.Unlock()
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "API rate limit")),
+		Message:  fmt.Sprintf(tr(l, "%s approaches Slack's limit of %d calls a minute. Consider caching or polling fewer conversations."), method, tierLimit(method)),
+		Priority: priority,
+	})
+}
+
+// budgetTable renders the Web API calls per method of the last minute as a
+// markdown table, most called method first.
+func (c *Plugin) budgetTable(l string) string {
+	rates := c.budget.rates(time.Now())
+	if len(rates) == 0 {
+		return tr(l, "No API calls in the last minute.") + "\n"
+	}
+	methods := make([]string, 0, len(rates))
+	for method := range rates {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if rates[methods[i]] != rates[methods[j]] {
+			return rates[methods[i]] > rates[methods[j]]
+		}
+		return methods[i] < methods[j]
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | %s | %s |\n|---|---|---|\n", tr(l, "Method"), tr(l, "Calls per minute"), tr(l, "Limit"))
+	for _, method := range methods {
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", escapeCell(method), rates[method], tierLimit(method))
+	}
+	return b.String()
+}
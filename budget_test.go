@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIBudget(t *testing.T) {
+	var b apiBudget
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i < 16; i++ {
+		assert.False(t, b.count("emoji.list", now))
+	}
+	assert.True(t, b.count("emoji.list", now))
+	assert.False(t, b.count("emoji.list", now))
+	assert.Nil(t, b.rates(now))
+	assert.Equal(t, map[string]int{"emoji.list": 17}, b.rates(now.Add(time.Minute)))
+
+	b.count("users.info", now.Add(time.Minute))
+	assert.Equal(t, map[string]int{"emoji.list": 17}, b.rates(now.Add(time.Minute)))
+	assert.Equal(t, map[string]int{"users.info": 1}, b.rates(now.Add(2*time.Minute)))
+	assert.Nil(t, b.rates(now.Add(3*time.Minute)))
+
+	assert.False(t, b.count("rtm.connect", now))
+	assert.False(t, b.count("rtm.connect", now))
+}
+
+func TestCountedClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":true,"user_id":"U1"}`)
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	api, err := c.config.countedClient(c)
+	assert.NoError(t, err)
+	for i := 0; i < 80; i++ {
+		_, err = api.AuthTest()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 80, c.budget.current["auth.test"])
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | API rate limit", h.sent[0].Title)
+	}
+}
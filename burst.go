@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// burstState tracks the current activity burst of a channel.
+type burstState struct {
+	channel    *slack.Channel
+	conv       string
+	title      string
+	team       string
+	suppressed int
+}
+
+// burst applies burst mode to a message in channel, labeled conv: the first
+// message of a burst is forwarded, the following ones within window are
+// suppressed and summarized when the window ends. title and team are those
+// of the summary.
+func (c *Plugin) burst(channel *slack.Channel, conv, title, team string, window time.Duration) (suppress bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b := c.bursts[channel.ID]; b != nil {
+		b.suppressed++
+		return true
+	}
+	if c.bursts == nil {
+		c.bursts = make(map[string]*burstState)
+	}
+	b := &burstState{channel: channel, conv: conv, title: title, team: team}
+	c.bursts[channel.ID] = b
+	time.AfterFunc(window, func() { c.safely(func() { c.endBurst(channel.ID, b) }) })
+	return false
+}
+
+// endBurst ends the burst b of channel and sends the number of suppressed
+// messages, if any, with a link opening the channel in Slack. The summary
+// passes the same filters as the messages of the channel.
+func (c *Plugin) endBurst(channel string, b *burstState) {
+	c.mu.Lock()
+	if c.bursts[channel] != b {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.bursts, channel)
+	n := b.suppressed
+	c.mu.Unlock()
+	if n == 0 {
+		return
+	}
+	now := time.Now()
+	if reason := c.filterReason(channel, now); reason != "" {
+		c.record(b.conv, b.title, filteredBy(string(reason)))
+		return
+	}
+	priority, reason := c.applyProfile(c.defaultPriority(), false, now)
+	if reason != "" {
+		c.record(b.conv, b.title, filteredBy(reason))
+		return
+	}
+	msg := plugin.Message{
+		Title:    b.title,
+		Message:  fmt.Sprintf(tr(c.locale(), "+%d more messages in %s, see Slack"), n, b.conv),
+		Priority: priority,
+	}
+	setExtra(&msg, "slack::message", "channel", channel)
+	setExtra(&msg, "client::notification", "click", map[string]string{
+		"url": "slack://channel?" + url.Values{"team": {b.team}, "id": {channel}}.Encode(),
+	})
+	c.deliver(b.channel, b.conv, msg)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurst(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	random := &slack.Channel{}
+	random.ID = "C1"
+	social := &slack.Channel{}
+	social.ID = "C2"
+	assert.False(t, c.burst(random, "#random", "Slack | Acme | random", "T1", time.Hour))
+	for i := 0; i < 3; i++ {
+		assert.True(t, c.burst(random, "#random", "Slack | Acme | random", "T1", time.Hour))
+	}
+	assert.False(t, c.burst(social, "#social", "Slack | Acme | social", "T1", time.Hour))
+
+	// The summary is sent when the window ends, linking to the channel.
+	c.endBurst("C1", c.bursts["C1"])
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme | random", h.sent[0].Title)
+		assert.Equal(t, "+3 more messages in #random, see Slack", h.sent[0].Message)
+		assert.Equal(t, map[string]string{"url": "slack://channel?id=C1&team=T1"}, h.sent[0].Extras["client::notification"].(map[string]interface{})["click"])
+	}
+	assert.False(t, c.burst(random, "#random", "Slack | Acme | random", "T1", time.Hour))
+
+	// A burst without suppressed messages ends silently.
+	c.endBurst("C2", c.bursts["C2"])
+	assert.Len(t, h.sent, 1)
+
+	// The summary respects mutes.
+	c.muted = map[string]time.Time{"C1": {}}
+	assert.True(t, c.burst(random, "#random", "Slack | Acme | random", "T1", time.Hour))
+	c.endBurst("C1", c.bursts["C1"])
+	assert.Len(t, h.sent, 1)
+	assert.Equal(t, filteredBy(string(ruleMuted)), c.recent[len(c.recent)-1].disposition)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// lookupTTL is how long looked up users and conversations are cached.
+const lookupTTL = time.Hour
+
+type cachedUser struct {
+	user    *slack.User
+	err     error
+	fetched time.Time
+}
+
+type cachedChannel struct {
+	channel *slack.Channel
+	fetched time.Time
+}
+
+// isRestrictedError reports whether err returned by the Slack Web API means
+// that the account may not see the requested object, as is the case for
+// guest accounts, or that the token lacks the scope or type the method
+// requires. Such lookups are not retried until the cache expires.
+func isRestrictedError(err error) bool {
+	switch err.Error() {
+	case "user_not_visible", "user_not_found", "restricted_action", "access_denied", "missing_scope", "not_allowed_token_type", "team_access_not_granted":
+		return true
+	}
+	return false
+}
+
+// userInfo looks up a user through the cache.
+func (c *Plugin) userInfo(id string) (*slack.User, error) {
+	c.mu.Lock()
+	e, ok := c.users[id]
+	c.mu.Unlock()
+	if ok && time.Since(e.fetched) < lookupTTL {
+		return e.user, e.err
+	}
+	user, err := c.slackAPI().GetUserInfo(id)
+	if err != nil && !isRestrictedError(err) {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.users == nil {
+		c.users = make(map[string]cachedUser)
+	}
+	c.users[id] = cachedUser{user: user, err: err, fetched: time.Now()}
+	c.mu.Unlock()
+	return user, err
+}
+
+// conversationInfo looks up a conversation through the cache.
+func (c *Plugin) conversationInfo(id string) (*slack.Channel, error) {
+	c.mu.Lock()
+	e, ok := c.channels[id]
+	c.mu.Unlock()
+	if ok && time.Since(e.fetched) < lookupTTL {
+		return e.channel, nil
+	}
+	channel, err := c.slackAPI().GetConversationInfo(id, false)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.channels == nil {
+		c.channels = make(map[string]cachedChannel)
+	}
+	c.channels[id] = cachedChannel{channel: channel, fetched: time.Now()}
+	c.mu.Unlock()
+	return channel, nil
+}
+
+// updateUser replaces the cached profile of a user, e.g. on user_change
+// events, so that changed names apply right away.
+func (c *Plugin) updateUser(user slack.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.users == nil {
+		c.users = make(map[string]cachedUser)
+	}
+	c.users[user.ID] = cachedUser{user: &user, fetched: time.Now()}
+}
+
+// renameChannel updates the name of a cached conversation.
+func (c *Plugin) renameChannel(id, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.channels[id]
+	if !ok {
+		return
+	}
+	renamed := *e.channel
+	renamed.Name, renamed.NameNormalized = name, name
+	e.channel = &renamed
+	c.channels[id] = e
+}
+
+// forgetChannel drops a conversation from the cache, e.g. after leaving it.
+func (c *Plugin) forgetChannel(id string) {
+	c.mu.Lock()
+	delete(c.channels, id)
+	c.mu.Unlock()
+}
+
+// storedCache is the persisted form of the lookup caches of a workspace.
+type storedCache struct {
+	TeamID   string                   `json:"teamId"`
+	Users    map[string]storedUser    `json:"users,omitempty"`
+	Channels map[string]storedChannel `json:"channels,omitempty"`
+	BotNames map[string]string        `json:"botNames,omitempty"`
+}
+
+type storedUser struct {
+	User    *slack.User `json:"user,omitempty"`
+	Err     string      `json:"err,omitempty"`
+	Fetched time.Time   `json:"fetched"`
+}
+
+type storedChannel struct {
+	Channel *slack.Channel `json:"channel"`
+	Fetched time.Time      `json:"fetched"`
+}
+
+// saveCaches persists the unexpired lookups, so that they need not be
+// repeated after a restart.
+func (c *Plugin) saveCaches() error {
+	now := time.Now()
+	c.mu.Lock()
+	cache := &storedCache{TeamID: c.teamID, Users: make(map[string]storedUser), Channels: make(map[string]storedChannel), BotNames: make(map[string]string)}
+	for id, e := range c.users {
+		if now.Sub(e.fetched) >= lookupTTL {
+			continue
+		}
+		u := storedUser{User: e.user, Fetched: e.fetched}
+		if e.err != nil {
+			u.Err = e.err.Error()
+		}
+		cache.Users[id] = u
+	}
+	for id, e := range c.channels {
+		if now.Sub(e.fetched) < lookupTTL {
+			cache.Channels[id] = storedChannel{Channel: e.channel, Fetched: e.fetched}
+		}
+	}
+	for id, name := range c.botNames {
+		cache.BotNames[id] = name
+	}
+	c.mu.Unlock()
+	return c.updateState(func(state *storedState) {
+		state.Cache = cache
+	})
+}
+
+// loadCaches fills the lookup caches with the lookups persisted for the
+// workspace the plugin is connected to. Expired lookups are repeated as usual.
+func (c *Plugin) loadCaches() error {
+	state, err := c.loadState()
+	if err != nil || state.Cache == nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state.Cache.TeamID != c.teamID {
+		return nil
+	}
+	c.users = make(map[string]cachedUser, len(state.Cache.Users))
+	for id, u := range state.Cache.Users {
+		e := cachedUser{user: u.User, fetched: u.Fetched}
+		if u.Err != "" {
+			e.err = errors.New(u.Err)
+		}
+		c.users[id] = e
+	}
+	c.channels = make(map[string]cachedChannel, len(state.Cache.Channels))
+	for id, ch := range state.Cache.Channels {
+		c.channels[id] = cachedChannel{channel: ch.Channel, fetched: ch.Fetched}
+	}
+	c.botNames = state.Cache.BotNames
+	return nil
+}
+
+// resetCaches drops all cached lookups, e.g. after reconnecting.
+func (c *Plugin) resetCaches() {
+	c.mu.Lock()
+	c.users = nil
+	c.channels = nil
+	c.botNames = nil
+	c.teamNames = nil
+	c.emoji = nil
+	c.emojiFetched = time.Time{}
+	c.mu.Unlock()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistCaches(t *testing.T) {
+	storage := &memoryStorage{}
+	c := &Plugin{teamID: "T1"}
+	c.SetStorageHandler(storage)
+	now := time.Now()
+	user := &slack.User{ID: "U1", Name: "alice"}
+	c.users = map[string]cachedUser{
+		"U1": {user: user, fetched: now},
+		"U2": {err: errors.New("user_not_visible"), fetched: now},
+		"U3": {user: &slack.User{ID: "U3"}, fetched: now.Add(-2 * lookupTTL)},
+	}
+	c.channels = map[string]cachedChannel{"C1": {channel: &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general"}}, fetched: now}}
+	c.botNames = map[string]string{"B1": "CI"}
+	assert.NoError(t, c.saveCaches())
+
+	restarted := &Plugin{teamID: "T1"}
+	restarted.SetStorageHandler(storage)
+	assert.NoError(t, restarted.loadCaches())
+	assert.Len(t, restarted.users, 2)
+	assert.Equal(t, "alice", restarted.users["U1"].user.Name)
+	assert.EqualError(t, restarted.users["U2"].err, "user_not_visible")
+	assert.Equal(t, "general", restarted.channels["C1"].channel.Name)
+	assert.Equal(t, "CI", restarted.botNames["B1"])
+
+	other := &Plugin{teamID: "T2"}
+	other.SetStorageHandler(storage)
+	assert.NoError(t, other.loadCaches())
+	assert.Empty(t, other.users)
+}
+
+func TestLiveCacheUpdates(t *testing.T) {
+	c := &Plugin{}
+	channel := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general"}}
+	c.channels = map[string]cachedChannel{"C1": {channel: channel, fetched: time.Now()}}
+	c.renameChannel("C1", "announcements")
+	assert.Equal(t, "announcements", c.channels["C1"].channel.Name)
+	assert.Equal(t, "general", channel.Name)
+	c.renameChannel("C2", "random")
+	assert.NotContains(t, c.channels, "C2")
+	c.forgetChannel("C1")
+	assert.Empty(t, c.channels)
+
+	c.updateUser(slack.User{ID: "U1", RealName: "Alice"})
+	user, err := c.userInfo("U1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", user.RealName)
+}
+
+func TestRestrictedLookups(t *testing.T) {
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := path.Base(r.URL.Path)
+		calls[method]++
+		switch method {
+		case "users.info":
+			fmt.Fprint(w, `{"ok":false,"error":"not_allowed_token_type"}`)
+		case "emoji.list":
+			fmt.Fprint(w, `{"ok":false,"error":"missing_scope"}`)
+		default:
+			fmt.Fprint(w, `{"ok":false,"error":"unknown_method"}`)
+		}
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+	channel := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: ":partyparrot:", Timestamp: "1.000000"}}, nil, channel)
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: ":shipit:", Timestamp: "2.000000"}}, nil, channel)
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Slack | ops | U2", h.sent[0].Title)
+		assert.Equal(t, ":partyparrot:", h.sent[0].Message)
+		assert.NotContains(t, h.sent[0].Extras, "client::notification")
+	}
+	assert.Equal(t, map[string]int{"users.info": 1, "emoji.list": 1}, calls)
+}
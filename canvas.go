@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// canvasQuietPeriod is the time after a canvas edit notification during
+// which further edits of the same canvas are not notified, since Slack
+// reports every saved change.
+const canvasQuietPeriod = 10 * time.Minute
+
+// canvas holds the details of a canvas or list, which the slack library does not decode.
+type canvas struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Filetype   string `json:"filetype"`
+	User       string `json:"user"`
+	LastEditor string `json:"last_editor"`
+	Permalink  string `json:"permalink"`
+}
+
+// isCanvas reports whether the file is a canvas or a list.
+func (f *canvas) isCanvas() bool {
+	switch f.Filetype {
+	case "canvas", "quip", "list":
+		return true
+	}
+	return false
+}
+
+// fetchCanvas reads a file with files.info.
+func (c *Plugin) fetchCanvas(id string) (*canvas, error) {
+	var resp struct {
+		File canvas `json:"file"`
+	}
+	if err := c.call("files.info", url.Values{"file": {id}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.File, nil
+}
+
+// follows reports whether the user follows changes of a canvas: those
+// they created and those listed in FollowedCanvases by ID or title.
+func (c *Plugin) follows(f *canvas) bool {
+	if f.User == c.userID() {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.config.FollowedCanvases {
+		if s == f.ID || strings.EqualFold(s, f.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCanvasChange notifies about an edit of a followed canvas or list by someone else.
+func (c *Plugin) handleCanvasChange(id string) {
+	c.mu.Lock()
+	enabled := c.config.NotifyCanvases
+	last := c.canvasNotified[id]
+	c.mu.Unlock()
+	if !enabled || time.Since(last) < canvasQuietPeriod {
+		return
+	}
+	f, err := c.fetchCanvas(id)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	if !f.isCanvas() || f.LastEditor == c.userID() || !c.follows(f) {
+		return
+	}
+	c.mu.Lock()
+	if c.canvasNotified == nil {
+		c.canvasNotified = make(map[string]time.Time)
+	}
+	c.canvasNotified[id] = time.Now()
+	c.mu.Unlock()
+	editor := f.LastEditor
+	if s, err := c.resolveSender(&slack.Msg{User: f.LastEditor}); err == nil {
+		editor = s.name
+	}
+	text := fmt.Sprintf(tr(c.locale(), "%s edited %s"), editor, f.Title)
+	c.notice(f.Title, f.Title, text+"\n"+f.Permalink, c.defaultPriority())
+}
+
+// handleCanvasComment notifies about a comment on a canvas or list mentioning the user.
+func (c *Plugin) handleCanvasComment(id string, comment slack.Comment) {
+	c.mu.Lock()
+	enabled := c.config.NotifyCanvases
+	c.mu.Unlock()
+	if !enabled || comment.User == c.userID() || !c.mentionsMe(comment.Comment) {
+		return
+	}
+	f, err := c.fetchCanvas(id)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	if !f.isCanvas() {
+		return
+	}
+	author := comment.User
+	if s, err := c.resolveSender(&slack.Msg{User: comment.User}); err == nil {
+		author = s.name
+	}
+	text := fmt.Sprintf(tr(c.locale(), "%s mentioned you in %s"), author, f.Title)
+	c.notice(f.Title, f.Title, text+": "+unescape(comment.Comment)+"\n"+f.Permalink, c.defaultPriority())
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanvasNotifications(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		calls++
+		switch r.Form.Get("file") {
+		case "F1":
+			fmt.Fprint(w, `{"ok":true,"file":{"id":"F1","title":"Roadmap","filetype":"canvas","user":"U1","last_editor":"U2","permalink":"https://acme.slack.com/docs/F1"}}`)
+		case "F2":
+			fmt.Fprint(w, `{"ok":true,"file":{"id":"F2","title":"Notes","filetype":"canvas","user":"U3","last_editor":"U2"}}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"file":{"id":"F3","title":"photo.png","filetype":"png","user":"U1","last_editor":"U2"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.config.Format = "plain"
+	c.users = map[string]cachedUser{"U2": {user: &slack.User{ID: "U2", RealName: "Bob"}, fetched: time.Now()}}
+	c.handleCanvasChange("F1")
+	assert.Empty(t, h.sent)
+	assert.Equal(t, 0, calls)
+
+	c.config.NotifyCanvases = true
+	c.handleCanvasChange("F1")
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Bob edited Roadmap\nhttps://acme.slack.com/docs/F1", h.sent[0].Message)
+	}
+	c.handleCanvasChange("F1")
+	assert.Len(t, h.sent, 1)
+	assert.Equal(t, 1, calls)
+
+	c.handleCanvasChange("F2")
+	assert.Len(t, h.sent, 1)
+	c.config.FollowedCanvases = []string{"notes"}
+	c.handleCanvasChange("F2")
+	assert.Len(t, h.sent, 2)
+	c.handleCanvasChange("F3")
+	assert.Len(t, h.sent, 2)
+
+	c.handleCanvasComment("F2", slack.Comment{User: "U2", Comment: "looks good"})
+	assert.Len(t, h.sent, 2)
+	c.handleCanvasComment("F2", slack.Comment{User: "U2", Comment: "<@U1> please review"})
+	if assert.Len(t, h.sent, 3) {
+		assert.Contains(t, h.sent[2].Message, "Bob mentioned you in Notes: ")
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// celebrationRe matches the texts Slackbot and celebration apps post for
+// birthdays and work anniversaries.
+var celebrationRe = regexp.MustCompile(`(?i)\b(birthday|anniversary|work-?iversary)\b`)
+
+// isCelebration reports whether a message is a celebration to be forwarded:
+// one posted by a bot listed in CelebrationBots, or by Slackbot or another
+// bot announcing a birthday or work anniversary.
+func (c *Plugin) isCelebration(s *sender, text string) bool {
+	c.mu.Lock()
+	enabled, bots := c.config.NotifyCelebrations, c.config.CelebrationBots
+	c.mu.Unlock()
+	if !enabled || (!s.bot && s.id != slackbotUser) {
+		return false
+	}
+	for _, b := range bots {
+		if b == s.id || strings.EqualFold(b, s.name) {
+			return true
+		}
+	}
+	return celebrationRe.MatchString(text)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCelebration(t *testing.T) {
+	c := &Plugin{config: &Config{NotifyCelebrations: true, CelebrationBots: []string{"Kudos"}}}
+	assert.True(t, c.isCelebration(&sender{id: slackbotUser, name: "Slackbot"}, "Today is Alice's birthday!"))
+	assert.True(t, c.isCelebration(&sender{id: "B1", name: "HR Bot", bot: true}, "Bob celebrates his 5 year work anniversary"))
+	assert.True(t, c.isCelebration(&sender{id: "B2", name: "kudos", bot: true}, "Congrats to the team"))
+	assert.False(t, c.isCelebration(&sender{id: "U1", name: "Carol"}, "my birthday is tomorrow"))
+	assert.False(t, c.isCelebration(&sender{id: "B1", name: "HR Bot", bot: true}, "Timesheets are due"))
+	c.config.NotifyCelebrations = false
+	assert.False(t, c.isCelebration(&sender{id: slackbotUser, name: "Slackbot"}, "Today is Alice's birthday!"))
+}
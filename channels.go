@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// ChannelConfig holds settings for the channels listed in Match.
+type ChannelConfig struct {
+	// Match lists channel names (with or without "#") or IDs. "@direct"
+	// matches all direct conversations, patterns starting with "^" are
+	// regular expressions matched against channel names, e.g.
+	// "^(team|proj)-backend", and patterns containing "*" match channel
+	// names by prefix or suffix conventions, e.g. "alert-*".
+	Match []string
+	// Priority overrides the priority of the channel's messages unless zero.
+	Priority int
+	// BypassQuietHours forwards the channel's messages during quiet hours.
+	BypassQuietHours bool
+	// DigestOnly only counts the channel's messages in the daily digest
+	// instead of forwarding them.
+	DigestOnly bool
+	// Window restricts forwarding to a time window, e.g. working hours.
+	// An empty window forwards around the clock.
+	Window Window
+	// OutsideWindow is "drop" (default) to discard messages outside the window
+	// or "queue" to deliver them once the window opens.
+	OutsideWindow string
+	// TitleTemplate and BodyTemplate replace the title and body of
+	// notifications, see templateData for the available fields.
+	TitleTemplate string
+	BodyTemplate  string
+	// HourlyCap is the maximum number of notifications per hour from each
+	// matching conversation. Further messages are dropped silently until
+	// the hour has passed. Zero disables the cap.
+	HourlyCap int
+	// DecayPeriod enables priority decay: every notification following
+	// another one within DecayPeriod is sent with a priority lowered by
+	// DecayStep, or at DecayPriority straight away if DecayStep is zero,
+	// but never below DecayPriority.
+	DecayPeriod   time.Duration
+	DecayPriority int
+	DecayStep     int
+	// BurstWindow enables burst mode: only the first message of a burst of
+	// activity is forwarded, the following ones are suppressed for
+	// BurstWindow and counted in a summary linking to the channel.
+	BurstWindow time.Duration
+}
+
+// Validate checks the channel configuration.
+func (cc ChannelConfig) Validate() error {
+	if err := validateChannelPatterns(cc.Match); err != nil {
+		return err
+	}
+	if err := cc.Window.Validate(); err != nil {
+		return err
+	}
+	if err := validateTemplates(cc.TitleTemplate, cc.BodyTemplate); err != nil {
+		return err
+	}
+	if cc.Priority < 0 || cc.Priority > 10 {
+		return errors.New("Priority must be between 0 and 10")
+	}
+	if cc.HourlyCap < 0 {
+		return errors.New("HourlyCap must not be negative")
+	}
+	if cc.DecayPriority < 0 || cc.DecayPriority > 10 {
+		return errors.New("DecayPriority must be between 0 and 10")
+	}
+	if cc.DecayStep < 0 {
+		return errors.New("DecayStep must not be negative")
+	}
+	if cc.BurstWindow < 0 {
+		return errors.New("BurstWindow must not be negative")
+	}
+	switch cc.OutsideWindow {
+	case "", "drop", "queue":
+		return nil
+	}
+	return fmt.Errorf("invalid OutsideWindow %q, expected drop or queue", cc.OutsideWindow)
+}
+
+// regexps caches the compiled regular expressions of the config, as they
+// are matched against every message.
+var regexps sync.Map
+
+// cachedRegexp compiles pattern once.
+func cachedRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
+
+// channelRegexp compiles a channel pattern starting with "^".
+func channelRegexp(pattern string) (*regexp.Regexp, error) {
+	re, err := cachedRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// validateChannelPatterns compiles the regular expressions among the
+// channel patterns and checks the wildcard patterns.
+func validateChannelPatterns(patterns []string) error {
+	for _, p := range patterns {
+		switch {
+		case strings.HasPrefix(p, "^"):
+			if _, err := channelRegexp(p); err != nil {
+				return err
+			}
+		case strings.Contains(p, "*"):
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid channel pattern %q: %v", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchChannel reports whether one of the patterns names the channel.
+func matchChannel(patterns []string, channel *slack.Channel) bool {
+	for _, p := range patterns {
+		if p == "@direct" && channel.IsIM {
+			return true
+		}
+		if strings.HasPrefix(p, "^") {
+			if re, err := channelRegexp(p); err == nil && channel.Name != "" && re.MatchString(channel.Name) {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(p, "*") {
+			if ok, _ := path.Match(strings.TrimPrefix(p, "#"), channel.Name); ok && channel.Name != "" {
+				return true
+			}
+			continue
+		}
+		if p == channel.ID || (channel.Name != "" && strings.TrimPrefix(p, "#") == channel.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// channelConfig returns the first channel configuration matching the channel.
+func (c *Plugin) channelConfig(channel *slack.Channel) *ChannelConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.config.Channels {
+		if matchChannel(c.config.Channels[i].Match, channel) {
+			cc := c.config.Channels[i]
+			return &cc
+		}
+	}
+	return nil
+}
+
+// channelName returns the configured alias of the channel or its name.
+func (c *Plugin) channelName(channel *slack.Channel) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if alias, ok := c.config.ChannelAliases[channel.ID]; ok {
+		return alias
+	}
+	if alias, ok := c.config.ChannelAliases[channel.Name]; ok && channel.Name != "" {
+		return alias
+	}
+	return channel.Name
+}
+
+// maxQueued bounds the number of messages held back for channel windows.
+const maxQueued = 100
+
+// queuedMessage is a message held back until its channel window opens.
+type queuedMessage struct {
+	window  Window
+	channel *slack.Channel
+	conv    string
+	msg     plugin.Message
+}
+
+// applyChannelWindow checks the channel's time window. If the message is not
+// to be sent now, it returns whether it has been queued or dropped. If the
+// queue is full, the oldest message is dropped.
+func (c *Plugin) applyChannelWindow(channel *slack.Channel, conv string, msg plugin.Message, now time.Time) string {
+	cc := c.channelConfig(channel)
+	if cc == nil || cc.Window.Empty() || cc.Window.Contains(now) {
+		return ""
+	}
+	if cc.OutsideWindow == "queue" {
+		c.mu.Lock()
+		full := len(c.queued) >= maxQueued
+		if full {
+			c.queued = c.queued[1:]
+		}
+		c.queued = append(c.queued, queuedMessage{window: cc.Window, channel: channel, conv: conv, msg: msg})
+		c.mu.Unlock()
+		if full {
+			c.logln("channel window queue full, dropping oldest message")
+		}
+		return "queued for channel window"
+	}
+	return filteredBy("channel window")
+}
+
+// allowCap checks the hourly cap of the channel's configuration and records
+// the notification if it passes.
+func (c *Plugin) allowCap(channel *slack.Channel, now time.Time) bool {
+	cc := c.channelConfig(channel)
+	if cc == nil || cc.HourlyCap <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	if c.caps == nil {
+		c.caps = make(map[string]*floodGate)
+	}
+	gate, ok := c.caps[channel.ID]
+	if !ok {
+		gate = &floodGate{}
+		c.caps[channel.ID] = gate
+	}
+	c.mu.Unlock()
+	return gate.allow(now, cc.HourlyCap, time.Hour)
+}
+
+// flushQueued delivers the queued messages whose window has opened, unless
+// their channel is muted or quiet hours began meanwhile.
+func (c *Plugin) flushQueued(now time.Time) {
+	c.mu.Lock()
+	var due []queuedMessage
+	pending := c.queued[:0]
+	for _, q := range c.queued {
+		if q.window.Contains(now) {
+			due = append(due, q)
+		} else {
+			pending = append(pending, q)
+		}
+	}
+	c.queued = pending
+	c.mu.Unlock()
+	for _, q := range due {
+		if reason := c.filterReason(q.channel.ID, now); reason != "" {
+			c.record(q.conv, q.msg.Title, filteredBy(string(reason)))
+			continue
+		}
+		c.deliver(q.channel, q.conv, q.msg)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchChannel(t *testing.T) {
+	channel := func(id, name string, im bool) *slack.Channel {
+		return &slack.Channel{GroupConversation: slack.GroupConversation{Name: name, Conversation: slack.Conversation{ID: id, IsIM: im}}}
+	}
+	patterns := []string{"#general", "C9", "@direct", "^(team|proj)-backend"}
+	assert.True(t, matchChannel(patterns, channel("C1", "general", false)))
+	assert.True(t, matchChannel(patterns, channel("C9", "random", false)))
+	assert.True(t, matchChannel(patterns, channel("D1", "", true)))
+	assert.True(t, matchChannel(patterns, channel("C2", "team-backend-alerts", false)))
+	assert.True(t, matchChannel(patterns, channel("C3", "proj-backend", false)))
+	assert.False(t, matchChannel(patterns, channel("C4", "team-frontend", false)))
+	assert.False(t, matchChannel(patterns, channel("C5", "my-team-backend", false)))
+	assert.True(t, matchChannel([]string{"alert-*"}, channel("C6", "alert-db", false)))
+	assert.True(t, matchChannel([]string{"#*-social"}, channel("C7", "berlin-social", false)))
+	assert.False(t, matchChannel([]string{"alert-*"}, channel("C8", "alerts", false)))
+	assert.Error(t, ChannelConfig{Match: []string{"^(team"}}.Validate())
+	assert.Error(t, ChannelConfig{Match: []string{"alert-[*"}}.Validate())
+}
+
+func TestChannelPolicies(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.QuietHours = Window{Days: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+	c.config.Channels = []ChannelConfig{{Match: []string{"gotify-*"}, Priority: 9, BypassQuietHours: true}}
+	c.sendTestMessage()
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, 9, h.sent[0].Priority)
+	}
+
+	c.config.Channels = []ChannelConfig{{Match: []string{"gotify-*"}, DigestOnly: true}}
+	c.config.QuietHours = Window{}
+	c.sendTestMessage()
+	assert.Len(t, h.sent, 1)
+	assert.Equal(t, map[string]int{"#gotify-test": 1}, c.digest.digestOnly)
+}
+
+func TestDigestOnlyRequiresDigestTime(t *testing.T) {
+	c := &Plugin{}
+	config := c.DefaultConfig().(*Config)
+	config.SlackToken = "xoxp-test"
+	config.Channels = []ChannelConfig{{Match: []string{"#random"}, DigestOnly: true}}
+	assert.EqualError(t, c.applyConfig(config), "channels [#random]: DigestOnly requires a DigestTime")
+}
+
+func TestHourlyCap(t *testing.T) {
+	c := &Plugin{config: &Config{Channels: []ChannelConfig{{Match: []string{"#random"}, HourlyCap: 2}}}}
+	random := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "random", Conversation: slack.Conversation{ID: "C1"}}}
+	general := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general", Conversation: slack.Conversation{ID: "C2"}}}
+	start := time.Now()
+	assert.True(t, c.allowCap(random, start))
+	assert.True(t, c.allowCap(random, start))
+	assert.False(t, c.allowCap(random, start.Add(time.Minute)))
+	assert.True(t, c.allowCap(general, start.Add(time.Minute)))
+	assert.True(t, c.allowCap(random, start.Add(time.Hour+time.Second)))
+}
+
+func TestApplyChannelWindow(t *testing.T) {
+	c := &Plugin{config: &Config{Channels: []ChannelConfig{
+		{Match: []string{"#random"}, Window: Window{Dates: []string{"2000-01-01"}}, OutsideWindow: "queue"},
+		{Match: []string{"#social"}, Window: Window{Dates: []string{"2000-01-01"}}},
+	}}}
+	random := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "random", Conversation: slack.Conversation{ID: "C1"}}}
+	social := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "social", Conversation: slack.Conversation{ID: "C2"}}}
+	general := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "general", Conversation: slack.Conversation{ID: "C3"}}}
+	now := time.Date(2000, 1, 2, 12, 0, 0, 0, time.Local)
+	assert.Equal(t, "", c.applyChannelWindow(general, "#general", plugin.Message{}, now))
+	assert.Equal(t, "", c.applyChannelWindow(random, "#random", plugin.Message{}, now.AddDate(0, 0, -1)))
+	assert.Equal(t, filteredBy("channel window"), c.applyChannelWindow(social, "#social", plugin.Message{}, now))
+	assert.Empty(t, c.queued)
+	for i := 0; i <= maxQueued; i++ {
+		assert.Equal(t, "queued for channel window", c.applyChannelWindow(random, "#random", plugin.Message{Title: strconv.Itoa(i)}, now))
+	}
+	// The oldest message is dropped when the queue is full.
+	if assert.Len(t, c.queued, maxQueued) {
+		assert.Equal(t, "1", c.queued[0].msg.Title)
+	}
+}
+
+func TestFlushQueued(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	always := Window{Days: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+	c.config.Channels = []ChannelConfig{{Match: []string{"#random", "#social"}, Window: always, OutsideWindow: "queue"}}
+	random := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "random", Conversation: slack.Conversation{ID: "C1"}}}
+	social := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "social", Conversation: slack.Conversation{ID: "C2"}}}
+	c.queued = []queuedMessage{
+		{window: always, channel: random, conv: "#random", msg: plugin.Message{Title: "due"}},
+		{window: Window{Dates: []string{"2000-01-01"}}, channel: random, conv: "#random", msg: plugin.Message{Title: "waiting"}},
+		{window: always, channel: social, conv: "#social", msg: plugin.Message{Title: "muted"}},
+	}
+	c.muted = map[string]time.Time{"C2": {}}
+	c.flushQueued(time.Now())
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "due", h.sent[0].Title)
+	}
+	if assert.Len(t, c.queued, 1) {
+		assert.Equal(t, "waiting", c.queued[0].msg.Title)
+	}
+	assert.Equal(t, filteredBy(string(ruleMuted)), c.recent[len(c.recent)-1].disposition)
+}
+
+func TestChannelAliases(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.ChannelAliases = map[string]string{"general": "all", "C2": "ops"}
+	c.users = map[string]cachedUser{"U2": {user: &slack.User{ID: "U2", RealName: "Alice"}, fetched: time.Now()}}
+	general := &slack.Channel{}
+	general.ID, general.Name = "C1", "general"
+	incidents := &slack.Channel{}
+	incidents.ID, incidents.Name = "C2", "incidents-2020"
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: "hi", Timestamp: "1.000000"}}, nil, general)
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C2", User: "U2", Text: "down", Timestamp: "2.000000"}}, nil, incidents)
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Slack | Acme | all | Alice", h.sent[0].Title)
+		assert.Equal(t, "Slack | Acme | ops | Alice", h.sent[1].Title)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	botParsers = append(botParsers, botParser{
+		name:  "ci",
+		bots:  []string{"jenkins", "gitlab", "github actions", "circleci", "buildkite", "travis"},
+		parse: parseCI,
+	})
+}
+
+// ciStatuses maps the words CI bots use for the outcome of a pipeline to
+// statuses and their priorities, most specific first.
+var ciStatuses = []struct {
+	re       *regexp.Regexp
+	status   string
+	priority int
+}{
+	{regexp.MustCompile(`(?i)\b(fail(ed|ure|ing)?|broken|errored)\b`), "failed", 8},
+	{regexp.MustCompile(`(?i)\bunstable\b`), "unstable", 6},
+	{regexp.MustCompile(`(?i)\b(cancell?ed|aborted)\b`), "canceled", 3},
+	{regexp.MustCompile(`(?i)\b(fixed|back to normal)\b`), "fixed", 4},
+	{regexp.MustCompile(`(?i)\b(succe(ss|ssful|eded)|passed)\b`), "success", 2},
+	{regexp.MustCompile(`(?i)\b(started|running|in progress)\b`), "started", 2},
+}
+
+// ciLinkRe finds the first link of a message.
+var ciLinkRe = regexp.MustCompile(`<(https?://[^|>]+)`)
+
+// parseCI turns a notification of Jenkins, GitLab, GitHub Actions or a
+// similar CI bot into "pipeline: status". Its kind is the status, i.e.
+// "failed", "unstable", "canceled", "fixed", "success" or "started", which
+// determines its priority: failures are high, successes low.
+func parseCI(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage {
+	texts := []string{msg.Text, renderBlocks(blocks)}
+	var pipeline, url string
+	for _, att := range msg.Attachments {
+		texts = append(texts, att.Pretext, att.Title, attachmentText(att))
+		if pipeline == "" {
+			pipeline, url = att.Title, att.TitleLink
+		}
+	}
+	all := strings.Join(texts, "\n")
+	p := &parsedMessage{}
+	for _, s := range ciStatuses {
+		if s.re.MatchString(all) {
+			p.kind, p.priority = s.status, s.priority
+			break
+		}
+	}
+	if p.kind == "" {
+		return nil
+	}
+	if pipeline == "" {
+		pipeline = collapseLines(plainText(strings.TrimSpace(all)), 1, true)
+	}
+	if url == "" {
+		if m := ciLinkRe.FindStringSubmatch(all); m != nil {
+			url = m[1]
+		}
+	}
+	p.text = shorten(plainText(pipeline), 120) + ": " + p.kind
+	p.url = url
+	return p
+}
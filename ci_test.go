@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCI(t *testing.T) {
+	c := &Plugin{config: &Config{}}
+	jenkins := &sender{id: "B1", name: "Jenkins", bot: true}
+	msg := &slack.Msg{Text: "web-deploy - #42 Failure after 3 min 12 sec (<https://ci.acme.dev/job/web-deploy/42/|Open>)"}
+	name, p, pc := c.parseBotMessage(jenkins, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "ci", name)
+		assert.Equal(t, "failed", p.kind)
+		assert.Equal(t, "https://ci.acme.dev/job/web-deploy/42/", p.url)
+		assert.Equal(t, 8, pc.priority(p, 5))
+	}
+
+	gitlab := &sender{id: "B2", name: "GitLab", bot: true}
+	msg = &slack.Msg{Attachments: []slack.Attachment{{
+		Title:     "Pipeline #123 of branch main",
+		TitleLink: "https://gitlab.acme.dev/web/-/pipelines/123",
+		Text:      "passed in 02:14",
+	}}}
+	c.config.Parsers = map[string]ParserConfig{"ci": {Drop: []string{"success"}}}
+	_, p, pc = c.parseBotMessage(gitlab, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "Pipeline #123 of branch main: success", p.text)
+		assert.True(t, pc.drops(p))
+	}
+
+	_, p, _ = c.parseBotMessage(jenkins, &slack.Msg{Text: "Jenkins is shutting down"}, nil)
+	assert.Nil(t, p)
+}
@@ -0,0 +1,191 @@
+// Command gotify-slack-check checks a Slack token the way the gotify-slack
+// plugin would use it: it verifies the token, lists the granted scopes,
+// tests whether it can receive events over RTM and lists the conversations
+// the user is a member of, telling which of them the plugin could forward
+// messages from.
+//
+//	gotify-slack-check -token xoxp-...
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// historyScopes maps the conversation types to the scope needed to read
+// their messages.
+var historyScopes = map[string]string{
+	"public_channel":  "channels:history",
+	"private_channel": "groups:history",
+	"mpim":            "mpim:history",
+	"im":              "im:history",
+}
+
+// legacyScopes grant access to everything the user can see.
+var legacyScopes = []string{"client", "read"}
+
+func main() {
+	token := flag.String("token", os.Getenv("SLACK_TOKEN"), "Slack API token, defaults to $SLACK_TOKEN")
+	apiURL := flag.String("api", slack.APIURL, "Slack API URL")
+	flag.Parse()
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "please pass a Slack token with -token or $SLACK_TOKEN")
+		os.Exit(2)
+	}
+	if err := check(os.Stdout, *apiURL, *token); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// check writes the report for token to w.
+func check(w io.Writer, apiURL, token string) error {
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	atr, scopes, err := authTest(apiURL, token)
+	if err != nil {
+		return err
+	}
+	api := slack.New(token, slack.OptionAPIURL(apiURL))
+	fmt.Fprintf(w, "Token of %s (%s) in %s (%s)\n", atr.User, atr.UserID, atr.Team, atr.TeamID)
+	if len(scopes) == 0 {
+		fmt.Fprintln(w, "Scopes: unknown")
+	} else {
+		fmt.Fprintln(w, "Scopes:", strings.Join(scopes, ", "))
+	}
+	for _, s := range []string{"users:read", "channels:read"} {
+		if !hasScope(scopes, s) {
+			fmt.Fprintf(w, "Warning: without %s, users and channels are shown by their IDs\n", s)
+		}
+	}
+	// The plugin receives events over RTM unless Transport is poll.
+	transport := ""
+	if _, _, err := api.ConnectRTM(); err != nil {
+		fmt.Fprintf(w, "RTM: not available (%s), only Transport: poll works, which forwards messages only\n", err)
+		transport = " with Transport: poll"
+	} else {
+		fmt.Fprintln(w, "RTM: ok")
+	}
+
+	params := &slack.GetConversationsForUserParameters{
+		UserID:          atr.UserID,
+		Types:           []string{"public_channel", "private_channel", "mpim", "im"},
+		Limit:           200,
+		ExcludeArchived: true,
+	}
+	var forwarded, skipped []string
+	for {
+		channels, cursor, err := api.GetConversationsForUser(params)
+		if err != nil {
+			return err
+		}
+		for _, ch := range channels {
+			kind := conversationType(ch)
+			name := conversationName(ch, kind)
+			if hasScope(scopes, historyScopes[kind]) {
+				forwarded = append(forwarded, name)
+			} else {
+				skipped = append(skipped, fmt.Sprintf("%s (missing %s)", name, historyScopes[kind]))
+			}
+		}
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+	sort.Strings(forwarded)
+	sort.Strings(skipped)
+	fmt.Fprintf(w, "\nMessages forwarded%s from %d conversations:\n", transport, len(forwarded))
+	for _, name := range forwarded {
+		fmt.Fprintln(w, "  "+name)
+	}
+	if len(skipped) != 0 {
+		fmt.Fprintf(w, "\nNot forwarded from %d conversations:\n", len(skipped))
+		for _, name := range skipped {
+			fmt.Fprintln(w, "  "+name)
+		}
+	}
+	return nil
+}
+
+// authTest verifies the token and returns the scopes Slack reports in the
+// X-OAuth-Scopes header, nil if it reports none. The slack library drops
+// the headers, so the call is made directly.
+func authTest(apiURL, token string) (*slack.AuthTestResponse, []string, error) {
+	resp, err := http.PostForm(apiURL+"auth.test", url.Values{"token": {token}})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		slack.SlackResponse
+		slack.AuthTestResponse
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, fmt.Errorf("auth.test: %s", err)
+	}
+	if !result.Ok {
+		return nil, nil, errors.New("auth.test: " + result.Error)
+	}
+	var scopes []string
+	for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return &result.AuthTestResponse, scopes, nil
+}
+
+// hasScope reports whether scope is granted. Unknown scopes are assumed to
+// be granted, as Slack does not report the scopes of every token.
+func hasScope(scopes []string, scope string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+		for _, l := range legacyScopes {
+			if s == l {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func conversationType(ch slack.Channel) string {
+	switch {
+	case ch.IsIM:
+		return "im"
+	case ch.IsMpIM:
+		return "mpim"
+	case ch.IsPrivate || ch.IsGroup:
+		return "private_channel"
+	}
+	return "public_channel"
+}
+
+func conversationName(ch slack.Channel, kind string) string {
+	switch kind {
+	case "im":
+		return "@" + ch.User
+	case "mpim":
+		return ch.Name + " (group message)"
+	case "private_channel":
+		return "#" + ch.Name + " (private)"
+	}
+	return "#" + ch.Name
+}
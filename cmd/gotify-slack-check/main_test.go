@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth.test":
+			w.Header().Set("X-OAuth-Scopes", "channels:history, im:history,users:read")
+			w.Write([]byte(`{"ok":true,"user":"marcel","user_id":"U1","team":"Acme","team_id":"T1"}`))
+		case "/rtm.connect":
+			w.Write([]byte(`{"ok":true,"url":"wss://example.com/"}`))
+		case "/users.conversations":
+			w.Write([]byte(`{"ok":true,"channels":[
+				{"id":"C1","name":"general"},
+				{"id":"G1","name":"secret","is_private":true},
+				{"id":"D1","is_im":true,"user":"U2"}]}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	assert.NoError(t, check(&out, srv.URL, "xoxp-test"))
+	assert.Equal(t, `Token of marcel (U1) in Acme (T1)
+Scopes: channels:history, im:history, users:read
+Warning: without channels:read, users and channels are shown by their IDs
+RTM: ok
+
+Messages forwarded from 2 conversations:
+  #general
+  @U2
+
+Not forwarded from 1 conversations:
+  #secret (private) (missing groups:history)
+`, out.String())
+}
+
+func TestCheckWithoutRTM(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth.test":
+			w.Write([]byte(`{"ok":true,"user":"marcel","user_id":"U1","team":"Acme","team_id":"T1"}`))
+		case "/rtm.connect":
+			w.Write([]byte(`{"ok":false,"error":"not_allowed_token_type"}`))
+		case "/users.conversations":
+			w.Write([]byte(`{"ok":true,"channels":[{"id":"C1","name":"general"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	assert.NoError(t, check(&out, srv.URL, "xoxb-test"))
+	assert.Contains(t, out.String(), "RTM: not available (not_allowed_token_type), only Transport: poll works")
+	assert.Contains(t, out.String(), "Messages forwarded with Transport: poll from 1 conversations:")
+}
+
+func TestCheckInvalidToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+	}))
+	defer srv.Close()
+	assert.EqualError(t, check(&bytes.Buffer{}, srv.URL, "xoxp-test"), "auth.test: invalid_auth")
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, hasScope(nil, "im:history"))
+	assert.True(t, hasScope([]string{"client"}, "im:history"))
+	assert.False(t, hasScope([]string{"channels:history"}, "im:history"))
+}
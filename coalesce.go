@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// batch collects consecutive messages of one sender in one conversation.
+type batch struct {
+	channel *slack.Channel
+	conv    string
+	msg     plugin.Message
+	texts   []string
+	timer   *time.Timer
+}
+
+// coalescer merges messages that arrive in quick succession.
+type coalescer struct {
+	mu      sync.Mutex
+	batches map[string]*batch
+}
+
+// coalesce holds msg back for the configured window and merges it with
+// further messages by the same sender in the same conversation.
+func (c *Plugin) coalesce(channel *slack.Channel, conv string, sender string, msg plugin.Message, window time.Duration) {
+	key := channel.ID + "/" + sender
+	c.batches.mu.Lock()
+	defer c.batches.mu.Unlock()
+	if b, ok := c.batches.batches[key]; ok {
+		b.texts = append(b.texts, msg.Message)
+		if msg.Priority > b.msg.Priority {
+			b.msg.Priority = msg.Priority
+		}
+		// A merged direct message or mention keeps the batch watched.
+		if ns, _ := b.msg.Extras["slack::message"].(map[string]interface{}); ns["notify"] != nil {
+			setExtra(&msg, "slack::message", "notify", ns["notify"])
+		}
+		b.msg.Extras = msg.Extras
+		b.timer.Reset(window)
+		return
+	}
+	if c.batches.batches == nil {
+		c.batches.batches = make(map[string]*batch)
+	}
+	b := &batch{channel: channel, conv: conv, msg: msg, texts: []string{msg.Message}}
+	b.timer = time.AfterFunc(window, func() { c.safely(func() { c.flushBatch(key) }) })
+	c.batches.batches[key] = b
+}
+
+// flushBatch delivers the merged messages of a batch, titled as its first
+// message with the number of messages added.
+func (c *Plugin) flushBatch(key string) {
+	c.batches.mu.Lock()
+	b, ok := c.batches.batches[key]
+	delete(c.batches.batches, key)
+	c.batches.mu.Unlock()
+	if !ok {
+		return
+	}
+	msg := b.msg
+	if n := len(b.texts); n > 1 {
+		msg.Title += " " + fmt.Sprintf(tr(c.locale(), "(%d messages)"), n)
+		msg.Message = strings.Join(b.texts, "\n")
+	}
+	c.deliver(b.channel, b.conv, msg)
+}
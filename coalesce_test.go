@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalesce(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.config.CoalesceWindow = time.Hour
+	c.config.Channels = []ChannelConfig{{Match: []string{"#general"}, TitleTemplate: "{{.User}} in #{{.Channel}}"}}
+	c.users = map[string]cachedUser{"U2": {user: &slack.User{ID: "U2", RealName: "Alice"}, fetched: time.Now()}}
+	channel := &slack.Channel{}
+	channel.ID = "C1"
+	channel.Name = "general"
+	post := func(text, ts string) {
+		c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: text, Timestamp: ts}}, nil, channel)
+	}
+	post("one", "1.000000")
+	post("two", "2.000000")
+	post("three", "3.000000")
+	assert.Empty(t, h.sent)
+	if assert.Contains(t, c.batches.batches, "C1/U2") {
+		// The batch is still waiting for its window to end.
+		assert.True(t, c.batches.batches["C1/U2"].timer.Stop())
+	}
+	c.flushBatch("C1/U2")
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Alice in #general (3 messages)", h.sent[0].Title)
+		assert.Equal(t, "one\ntwo\nthree", h.sent[0].Message)
+	}
+
+	post("four", "4.000000")
+	c.batches.batches["C1/U2"].timer.Stop()
+	c.flushBatch("C1/U2")
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Alice in #general", h.sent[1].Title)
+		assert.Equal(t, "four", h.sent[1].Message)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+)
+
+// maskedSecret replaces secrets in exported configs. Importing it keeps the current secret.
+const maskedSecret = "********"
+
+// importedConfig is a config imported through the config route. It replaces
+// the config stored by gotify as long as gotify passes the config whose hash
+// is Base, i.e. until the config is changed in gotify.
+type importedConfig struct {
+	Base   string `json:"base"`
+	Config string `json:"config"`
+}
+
+// configHash identifies a config.
+func configHash(config *Config) string {
+	b, _ := yaml.Marshal(config)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// secrets returns pointers to the secret fields of config.
+func (conf *Config) secrets() []*string {
+	return []*string{&conf.SlackToken, &conf.RefreshToken, &conf.ClientSecret, &conf.SigningSecret,
+		&conf.GotifyClientToken, &conf.MirrorSecret, &conf.MQTTPassword, &conf.AdminSecret}
+}
+
+// maskSecrets replaces the secrets of config with maskedSecret. The
+// AppTokens map is copied, as it is shared with the config it was copied from.
+func (conf *Config) maskSecrets() {
+	for _, s := range conf.secrets() {
+		if *s != "" {
+			*s = maskedSecret
+		}
+	}
+	if conf.AppTokens != nil {
+		tokens := make(map[string]string, len(conf.AppTokens))
+		for conv := range conf.AppTokens {
+			tokens[conv] = maskedSecret
+		}
+		conf.AppTokens = tokens
+	}
+}
+
+// restoreSecrets takes masked or missing secrets from current. Masked app
+// tokens of conversations current has no token for are dropped.
+func (conf *Config) restoreSecrets(current *Config) {
+	secrets := current.secrets()
+	for i, s := range conf.secrets() {
+		if *s == "" || *s == maskedSecret {
+			*s = *secrets[i]
+		}
+	}
+	for conv, token := range conf.AppTokens {
+		if token == "" || token == maskedSecret {
+			if current.AppTokens[conv] != "" {
+				conf.AppTokens[conv] = current.AppTokens[conv]
+			} else {
+				delete(conf.AppTokens, conv)
+			}
+		}
+	}
+}
+
+// importedConfig returns the imported config replacing the config gotify passed, if any.
+func (c *Plugin) importedConfig(base *Config) (*Config, error) {
+	hash := configHash(base)
+	c.mu.Lock()
+	c.baseConfig = base
+	c.mu.Unlock()
+	state, err := c.loadState()
+	if err != nil || state.Import == nil {
+		return nil, err
+	}
+	if state.Import.Base != hash {
+		return nil, c.updateState(func(s *storedState) { s.Import = nil })
+	}
+	config := c.DefaultConfig().(*Config)
+	if err := yaml.Unmarshal([]byte(state.Import.Config), config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// handleExportConfig serves the current config as YAML with its secrets masked.
+func (c *Plugin) handleExportConfig(ctx *gin.Context) {
+	if !c.authorized(ctx) {
+		return
+	}
+	c.mu.Lock()
+	var config Config
+	if c.config != nil {
+		config = *c.config
+	}
+	c.mu.Unlock()
+	config.maskSecrets()
+	b, err := yaml.Marshal(&config)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.Data(http.StatusOK, "application/x-yaml", b)
+}
+
+// handleImportConfig applies a config given as YAML or JSON. Masked or
+// missing secrets are taken from the current config, so that rule sets can
+// be shared between users. The imported config is kept in the plugin
+// storage until the config is changed in gotify.
+func (c *Plugin) handleImportConfig(ctx *gin.Context) {
+	if !c.authorized(ctx) {
+		return
+	}
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	config := c.DefaultConfig().(*Config)
+	if err := yaml.Unmarshal(body, config); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.mu.Lock()
+	base := c.baseConfig
+	c.mu.Unlock()
+	if base != nil {
+		config.restoreSecrets(base)
+	}
+	if err := c.applyConfig(config); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if base != nil {
+		b, err := yaml.Marshal(config)
+		if err == nil {
+			err = c.updateState(func(s *storedState) {
+				s.Import = &importedConfig{Base: configHash(base), Config: string(b)}
+			})
+		}
+		if err != nil {
+			c.logln(err)
+		}
+	}
+	ctx.String(http.StatusOK, tr(c.locale(), "The config was imported."))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportedConfig(t *testing.T) {
+	c := &Plugin{}
+	c.SetStorageHandler(&memoryStorage{})
+	base := &Config{SlackToken: "xoxp-1", Locale: "en"}
+	assert.NoError(t, c.updateState(func(s *storedState) {
+		s.Import = &importedConfig{Base: configHash(base), Config: "slacktoken: xoxp-1\nlocale: de\nfloodlimit: 5\n"}
+	}))
+
+	imported, err := c.importedConfig(base)
+	assert.NoError(t, err)
+	if assert.NotNil(t, imported) {
+		assert.Equal(t, "de", imported.Locale)
+		assert.Equal(t, 5, imported.FloodLimit)
+		assert.Equal(t, " | ", imported.TitleSeparator)
+	}
+
+	// Changing the config in gotify discards the import.
+	imported, err = c.importedConfig(&Config{SlackToken: "xoxp-1", Locale: "de"})
+	assert.NoError(t, err)
+	assert.Nil(t, imported)
+	state, _ := c.loadState()
+	assert.Nil(t, state.Import)
+}
+
+func TestMaskSecrets(t *testing.T) {
+	current := &Config{SlackToken: "xoxp-1", AppTokens: map[string]string{"#general": "A1", "@alice": "A2"}}
+	exported := *current
+	exported.maskSecrets()
+	assert.Equal(t, maskedSecret, exported.SlackToken)
+	assert.Equal(t, map[string]string{"#general": maskedSecret, "@alice": maskedSecret}, exported.AppTokens)
+	assert.Equal(t, "A1", current.AppTokens["#general"])
+
+	exported.AppTokens["@bob"] = maskedSecret
+	exported.AppTokens["@alice"] = "A3"
+	exported.restoreSecrets(current)
+	assert.Equal(t, "xoxp-1", exported.SlackToken)
+	assert.Equal(t, map[string]string{"#general": "A1", "@alice": "A3"}, exported.AppTokens)
+}
+
+func TestConfigRoutesRequireSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.SetStorageHandler(&memoryStorage{})
+	r := gin.New()
+	c.RegisterWebhook("/", r.Group("/"))
+	request := func(method, secret, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/config", strings.NewReader(body))
+		if secret != "" {
+			req.Header.Set(adminSecretHeader, secret)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusForbidden, request("GET", "", "").Code)
+
+	c.config.AdminSecret = "admin"
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "wrong", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "wrong", "mirrorurl: https://evil.example.com\n").Code)
+	assert.Empty(t, c.config.MirrorURL)
+	w := request("GET", "admin", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "adminsecret: '"+maskedSecret+"'")
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// connState is the lifecycle state of the connection to Slack.
+//
+//	disabled → connecting → connected → (connection lost) → backoff → connecting …
+//	connecting or connected → (no usable token) → waiting for a new config
+//	any state → stopping → disabled
+type connState int
+
+const (
+	stateDisabled connState = iota
+	stateConnecting
+	stateConnected
+	stateBackoff
+	stateWaiting
+	stateStopping
+)
+
+func (s connState) String() string {
+	switch s {
+	case stateConnecting:
+		return "connecting"
+	case stateConnected:
+		return "connected"
+	case stateBackoff:
+		return "waiting to reconnect"
+	case stateWaiting:
+		return "waiting for a new config"
+	case stateStopping:
+		return "stopping"
+	}
+	return "disabled"
+}
+
+const (
+	minConnectBackoff = 5 * time.Second
+	maxConnectBackoff = 5 * time.Minute
+)
+
+// errNoToken stops connecting until a token is configured.
+var errNoToken = errors.New("no Slack token configured")
+
+// authFailure wraps an error meaning that the token can no longer be used,
+// e.g. because it has been revoked. Like errNoToken, it stops connecting
+// until the config changes.
+type authFailure struct {
+	err error
+}
+
+func (e authFailure) Error() string {
+	return e.err.Error()
+}
+
+// errReconnect ends a connection that is to be reestablished right away,
+// e.g. to use a rotated token.
+var errReconnect = errors.New("reconnecting")
+
+// connectionState returns the current state of the connection.
+func (c *Plugin) connectionState() connState {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.state
+}
+
+// start starts a new run of the connection. c.connMu must be held.
+// The run waits for the previous one to end, so that there is never more
+// than one connection and event loop.
+func (c *Plugin) start() {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	prev := c.stopped
+	c.done, c.stopped = done, stopped
+	c.state = stateConnecting
+	go c.safely(func() { c.run(done, stopped, prev) })
+}
+
+// restart reconnects to apply a new config, unless the plugin is disabled.
+func (c *Plugin) restart() {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.state != stateDisabled && c.state != stateStopping {
+		c.stop()
+		c.start()
+	}
+}
+
+// stop ends the current run of the connection. c.connMu must be held.
+func (c *Plugin) stop() {
+	if c.state == stateDisabled || c.state == stateStopping {
+		return
+	}
+	c.state = stateStopping
+	close(c.done)
+}
+
+// setState sets the state on behalf of the run identified by done, unless
+// that run has been superseded.
+func (c *Plugin) setState(done chan struct{}, s connState) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.done == done && c.state != stateStopping {
+		c.state = s
+	}
+}
+
+// run connects to Slack and reconnects with backoff until done is closed.
+func (c *Plugin) run(done, stopped, prev chan struct{}) {
+	defer close(stopped)
+	defer func() {
+		c.connMu.Lock()
+		if c.done == done {
+			c.state = stateDisabled
+		}
+		c.connMu.Unlock()
+	}()
+	if prev != nil {
+		<-prev
+	}
+	backoff := minConnectBackoff
+	for {
+		connected, err := c.connect(done)
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if connected {
+			backoff = minConnectBackoff
+		}
+		if err == errReconnect {
+			c.setState(done, stateConnecting)
+			continue
+		}
+		if err == errNoToken {
+			c.mu.Lock()
+			c.fault = err
+			c.mu.Unlock()
+			c.setState(done, stateWaiting)
+			<-done
+			return
+		}
+		if _, ok := err.(authFailure); ok {
+			c.fail(err)
+			c.setState(done, stateWaiting)
+			<-done
+			return
+		}
+		if err != nil {
+			c.fail(err)
+		}
+		c.setState(done, stateBackoff)
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+		c.setState(done, stateConnecting)
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+// connect establishes a connection to Slack and handles its events until
+// done is closed or the connection has to be given up. connected tells
+// whether the connection had been established.
+func (c *Plugin) connect(done chan struct{}) (connected bool, err error) {
+	if err := c.refreshConfig(); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	if config.SlackToken == "" {
+		return false, errNoToken
+	}
+	api, err := config.countedClient(c)
+	if err != nil {
+		return false, err
+	}
+	dialer, err := config.dialer()
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	c.api = api
+	c.mu.Unlock()
+	atr, err := api.AuthTest()
+	if err != nil && isAuthError(err) {
+		return false, authFailure{err}
+	}
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	c.uid = atr.UserID
+	c.team = atr.Team
+	c.teamID = atr.TeamID
+	c.fault = nil
+	c.healthWarned = false
+	c.lastHealthCheck = time.Now()
+	c.mu.Unlock()
+	c.resetCaches()
+	if !c.cachesLoaded {
+		// Lookups persisted at the last shutdown speed up the cold start;
+		// reconnects start from scratch.
+		c.cachesLoaded = true
+		if err := c.loadCaches(); err != nil {
+			c.logln(err)
+		}
+	}
+	defer func() {
+		if err := c.saveCaches(); err != nil {
+			c.logln(err)
+		}
+	}()
+	if err := c.loadPrefs(); err != nil {
+		c.logln(err)
+	}
+	if err := c.loadGroups(); err != nil {
+		c.logln(err)
+	}
+	if err := c.loadQuietHours(); err != nil {
+		c.logln(err)
+	}
+	if err := c.loadOffsets(); err != nil {
+		c.logln(err)
+	}
+	var events <-chan slack.RTMEvent
+	var shared *sharedConnection
+	stopShared := make(chan struct{})
+	defer close(stopShared)
+	if config.ShareConnection {
+		events, shared = c.shareConnection(config.SlackToken, stopShared)
+	}
+	switch {
+	case events != nil:
+		c.logln("sharing the connection of another user with the same Slack token")
+	case config.Transport == "poll":
+		feed, stop := make(chan slack.RTMEvent), make(chan struct{})
+		go c.safely(func() { c.poll(feed, stop) })
+		defer close(stop)
+		events = feed
+	default:
+		rtm := api.NewRTM(slack.RTMOptionDialer(dialer))
+		go rtm.ManageConnection()
+		defer func() {
+			if err := rtm.Disconnect(); err != nil && err != slack.ErrAlreadyDisconnected {
+				c.logln(err)
+			}
+		}()
+		events = rtm.IncomingEvents
+	}
+	if shared != nil {
+		events = shared.forward(events, stopShared)
+	}
+	queued, stopQueue := make(chan slack.RTMEvent), make(chan struct{})
+	go c.safely(func() { c.queue(events, queued, stopQueue) })
+	defer close(stopQueue)
+	c.setState(done, stateConnected)
+	for {
+		panicked, err := c.runEventLoop(queued, done)
+		if !panicked {
+			return true, err
+		}
+	}
+}
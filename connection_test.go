@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForState(t *testing.T, c *Plugin, want connState) {
+	deadline := time.Now().Add(time.Second)
+	for c.connectionState() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state is %v, want %v", c.connectionState(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConnectionLifecycle(t *testing.T) {
+	c := &Plugin{config: &Config{}, msgHandler: &fakeHandler{}}
+	c.connMu.Lock()
+	c.start()
+	first := c.stopped
+	c.connMu.Unlock()
+	// Without a token the run waits for a new config.
+	waitForState(t, c, stateWaiting)
+	assert.Equal(t, errNoToken, c.fault)
+
+	c.restart()
+	c.restart()
+	<-first
+	waitForState(t, c, stateWaiting)
+
+	assert.NoError(t, c.Disable())
+	c.connMu.Lock()
+	stopped := c.stopped
+	c.connMu.Unlock()
+	<-stopped
+	assert.Equal(t, stateDisabled, c.connectionState())
+
+	// Restarting a disabled plugin does not connect.
+	c.restart()
+	assert.Equal(t, stateDisabled, c.connectionState())
+}
+
+func TestConnectionStopsOnAuthFailure(t *testing.T) {
+	calls := 0
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		fmt.Fprint(w, `{"ok":false,"error":"account_inactive"}`)
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{config: &Config{SlackToken: "xoxp-test", APIURL: srv.URL}, msgHandler: h}
+	c.connMu.Lock()
+	c.start()
+	c.connMu.Unlock()
+	waitForState(t, c, stateWaiting)
+	mu.Lock()
+	assert.Equal(t, 1, calls)
+	mu.Unlock()
+	assert.EqualError(t, c.fault, "account_inactive")
+	if assert.Len(t, h.sent, 1) {
+		assert.Contains(t, h.sent[0].Message, "account_inactive")
+	}
+	assert.NoError(t, c.Disable())
+}
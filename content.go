@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// validateDropPatterns checks whether the content filter patterns compile.
+func validateDropPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := cachedRegexp(p); err != nil {
+			return fmt.Errorf("invalid drop pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// contentReason returns why a message is dropped by the content filter, or
+// an empty string if it is not. text is the formatted message including
+// its attachments.
+func (c *Plugin) contentReason(msg *slack.Msg, text string) string {
+	c.mu.Lock()
+	patterns, subtypes, gifs := c.config.DropPatterns, c.config.DropSubtypes, c.config.DropGIFs
+	c.mu.Unlock()
+	for _, s := range subtypes {
+		if s == msg.SubType {
+			return "subtype " + s
+		}
+	}
+	if gifs && isGIFOnly(msg) {
+		return "GIF"
+	}
+	for _, p := range patterns {
+		if re, err := cachedRegexp(p); err == nil && re.MatchString(text) {
+			return "content"
+		}
+	}
+	return ""
+}
+
+// isGIFOnly reports whether a message consists of nothing but animated
+// images, as posted e.g. by /giphy.
+func isGIFOnly(msg *slack.Msg) bool {
+	if strings.TrimSpace(msg.Text) != "" && !strings.HasPrefix(msg.Text, "/giphy") {
+		return false
+	}
+	n := 0
+	for _, att := range msg.Attachments {
+		if !strings.Contains(strings.ToLower(att.ImageURL), ".gif") {
+			return false
+		}
+		n++
+	}
+	for _, f := range msg.Files {
+		if f.Filetype != "gif" {
+			return false
+		}
+		n++
+	}
+	return n != 0
+}
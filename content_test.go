@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentReason(t *testing.T) {
+	c := &Plugin{config: &Config{DropPatterns: []string{`(?i)lunch order`}, DropSubtypes: []string{"channel_topic"}, DropGIFs: true}}
+	assert.Equal(t, "content", c.contentReason(&slack.Msg{}, "attachment: Lunch Order for today"))
+	assert.Equal(t, "subtype channel_topic", c.contentReason(&slack.Msg{SubType: "channel_topic"}, "set the topic"))
+	gif := &slack.Msg{Text: "/giphy cats", Attachments: []slack.Attachment{{ImageURL: "https://media.giphy.com/cats.gif"}}}
+	assert.Equal(t, "GIF", c.contentReason(gif, "cats"))
+	assert.Equal(t, "", c.contentReason(&slack.Msg{Text: "deploy done"}, "deploy done"))
+	assert.Error(t, validateDropPatterns([]string{"["}))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+)
+
+// debugEvent is a Slack event as received, kept to diagnose why a message
+// was or was not forwarded.
+type debugEvent struct {
+	Time time.Time       `json:"time"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// captureEvent keeps the JSON of an event in the ring buffer of the last
+// DebugEvents events.
+func (c *Plugin) captureEvent(ev slack.RTMEvent) {
+	c.mu.Lock()
+	size := c.config.DebugEvents
+	c.mu.Unlock()
+	if size <= 0 {
+		return
+	}
+	var data []byte
+	var err error
+	if e, ok := ev.Data.(*slack.UnmarshallingErrorEvent); ok {
+		// The error carries the raw event the library could not decode.
+		data, err = json.Marshal(e.Error())
+	} else {
+		data, err = json.Marshal(ev.Data)
+	}
+	if err != nil {
+		data, _ = json.Marshal(err.Error())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.debugEvents = append(c.debugEvents, debugEvent{Time: time.Now(), Type: ev.Type, Data: data})
+	if len(c.debugEvents) > size {
+		c.debugEvents = c.debugEvents[len(c.debugEvents)-size:]
+	}
+}
+
+// handleEvents serves the captured events as JSON, oldest first. They
+// include the text of messages, so AdminSecret is required.
+func (c *Plugin) handleEvents(ctx *gin.Context) {
+	if !c.authorized(ctx) {
+		return
+	}
+	c.mu.Lock()
+	enabled := c.config != nil && c.config.DebugEvents > 0
+	events := append([]debugEvent{}, c.debugEvents...)
+	c.mu.Unlock()
+	if !enabled {
+		ctx.String(http.StatusNotFound, "debug events are not captured, set DebugEvents")
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+// debugTable renders the types of the captured events as a markdown table, newest first.
+func (c *Plugin) debugTable(l string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.debugEvents) == 0 {
+		return tr(l, "No events yet.") + "\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | %s |\n|---|---|\n", tr(l, "Time"), tr(l, "Event"))
+	for i := len(c.debugEvents) - 1; i >= 0; i-- {
+		e := c.debugEvents[i]
+		fmt.Fprintf(&b, "| %s | %s |\n", e.Time.Format("15:04:05"), escapeCell(e.Type))
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureEvent(t *testing.T) {
+	c := &Plugin{config: &Config{DebugEvents: 2}}
+	for _, text := range []string{"one", "two", "three"} {
+		c.captureEvent(slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: slack.Msg{Text: text}}})
+	}
+	assert.Len(t, c.debugEvents, 2)
+	assert.Contains(t, string(c.debugEvents[0].Data), `"text":"two"`)
+	assert.Contains(t, string(c.debugEvents[1].Data), `"text":"three"`)
+
+	c.config.DebugEvents = 0
+	c.captureEvent(slack.RTMEvent{Type: "hello", Data: &slack.HelloEvent{}})
+	assert.Len(t, c.debugEvents, 2)
+}
+
+func TestHandleEventsRequiresSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := &Plugin{config: &Config{DebugEvents: 2}}
+	c.captureEvent(slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: slack.Msg{Text: "secret plans"}}})
+	r := gin.New()
+	c.RegisterWebhook("/", r.Group("/"))
+	get := func(secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/events", nil)
+		req.Header.Set(adminSecretHeader, secret)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusForbidden, get("").Code)
+	c.config.AdminSecret = "admin"
+	w := get("wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret plans")
+	w = get("admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "secret plans")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// decayState tracks the recent notifications of a channel for priority decay.
+type decayState struct {
+	last  time.Time
+	count int
+}
+
+// decayedPriority returns the priority of the n-th notification in a row:
+// each one lowers the priority by step, or straight to floor if step is zero.
+func decayedPriority(priority, floor, step, n int) int {
+	if n == 0 || priority <= floor {
+		return priority
+	}
+	if step <= 0 {
+		return floor
+	}
+	if p := priority - n*step; p > floor {
+		return p
+	}
+	return floor
+}
+
+// applyDecay lowers the priority of a notification from a channel that has
+// sent notifications recently, as configured by the channel's Decay
+// settings. The priority is restored once the channel has been quiet for
+// DecayPeriod.
+func (c *Plugin) applyDecay(channel *slack.Channel, priority int, now time.Time) int {
+	cc := c.channelConfig(channel)
+	if cc == nil || cc.DecayPeriod <= 0 {
+		return priority
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.decay == nil {
+		c.decay = make(map[string]*decayState)
+	}
+	d, ok := c.decay[channel.ID]
+	if !ok {
+		d = &decayState{}
+		c.decay[channel.ID] = d
+	}
+	if now.Sub(d.last) >= cc.DecayPeriod {
+		d.count = 0
+	}
+	p := decayedPriority(priority, cc.DecayPriority, cc.DecayStep, d.count)
+	d.count++
+	d.last = now
+	return p
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDecay(t *testing.T) {
+	c := &Plugin{config: &Config{Channels: []ChannelConfig{{Match: []string{"#alerts"}, DecayPeriod: 10 * time.Minute, DecayPriority: 2}}}}
+	alerts := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "alerts", Conversation: slack.Conversation{ID: "C1"}}}
+	start := time.Now()
+	assert.Equal(t, 5, c.applyDecay(alerts, 5, start))
+	assert.Equal(t, 2, c.applyDecay(alerts, 5, start.Add(time.Minute)))
+	assert.Equal(t, 2, c.applyDecay(alerts, 5, start.Add(9*time.Minute)))
+	assert.Equal(t, 5, c.applyDecay(alerts, 5, start.Add(20*time.Minute)))
+
+	assert.Equal(t, 4, decayedPriority(5, 1, 1, 1))
+	assert.Equal(t, 1, decayedPriority(5, 1, 2, 3))
+	assert.Equal(t, 1, decayedPriority(1, 3, 0, 2))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// digestTop is the number of busiest conversations listed in the digest.
+const digestTop = 3
+
+// digest collects the statistics of the daily summary since it was last sent.
+type digest struct {
+	forwarded int
+	filtered  int
+	// conversations counts the forwarded messages by conversation label.
+	conversations map[string]int
+	// digestOnly counts the messages of digest-only channels by conversation label.
+	digestOnly map[string]int
+	// unanswered holds the labels of direct conversations by channel ID
+	// whose last message is not from the user.
+	unanswered map[string]string
+	// sent is the date the digest was last sent on.
+	sent string
+}
+
+// countDigest records a handled message for the digest. c.mu must be held.
+func (c *Plugin) countDigest(conv, disposition string) {
+	switch {
+	case disposition == dispositionForwarded:
+		c.digest.forwarded++
+		if c.digest.conversations == nil {
+			c.digest.conversations = make(map[string]int)
+		}
+		c.digest.conversations[conv]++
+	case disposition == dispositionDigestOnly:
+		if c.digest.digestOnly == nil {
+			c.digest.digestOnly = make(map[string]int)
+		}
+		c.digest.digestOnly[conv]++
+	case strings.HasPrefix(disposition, dispositionDryRun), strings.HasPrefix(disposition, "error"):
+	default:
+		c.digest.filtered++
+	}
+}
+
+// trackAnswer updates the unanswered direct conversations with a message
+// in the direct conversation channel, labeled conv.
+func (c *Plugin) trackAnswer(channel, conv string, mine bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if mine {
+		delete(c.digest.unanswered, channel)
+		return
+	}
+	if c.digest.unanswered == nil {
+		c.digest.unanswered = make(map[string]string)
+	}
+	c.digest.unanswered[channel] = conv
+}
+
+// sendDigest sends the daily summary if DigestTime is now and it has not
+// been sent today, and starts collecting the next one.
+func (c *Plugin) sendDigest(now time.Time) {
+	c.mu.Lock()
+	at, l := c.config.DigestTime, c.config.Locale
+	today := now.Format("2006-01-02")
+	due, err := parseClock(at)
+	if at == "" || err != nil || now.Hour()*60+now.Minute() < due || c.digest.sent == today {
+		c.mu.Unlock()
+		return
+	}
+	text := c.digest.text(l)
+	c.digest = digest{sent: today, unanswered: c.digest.unanswered}
+	c.mu.Unlock()
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Daily summary")),
+		Message:  text,
+		Priority: c.defaultPriority(),
+	})
+}
+
+// text renders the digest in the given locale.
+func (d *digest) text(l string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr(l, "Forwarded: %d, filtered: %d"), d.forwarded, d.filtered)
+	if top := rankConversations(d.conversations, digestTop); top != "" {
+		b.WriteString("\n" + tr(l, "Top conversations") + ": " + top)
+	}
+	if only := rankConversations(d.digestOnly, 0); only != "" {
+		b.WriteString("\n" + tr(l, "Only in this summary") + ": " + only)
+	}
+	if len(d.unanswered) != 0 {
+		var convs []string
+		for _, conv := range d.unanswered {
+			convs = append(convs, conv)
+		}
+		sort.Strings(convs)
+		b.WriteString("\n" + tr(l, "Unanswered direct messages") + ": " + strings.Join(convs, ", "))
+	}
+	return b.String()
+}
+
+// rankConversations lists the conversations with their counts, busiest
+// first, limited to the top n unless n is zero.
+func rankConversations(counts map[string]int, n int) string {
+	type count struct {
+		conv string
+		n    int
+	}
+	var ranked []count
+	for conv, n := range counts {
+		ranked = append(ranked, count{conv, n})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].n != ranked[j].n {
+			return ranked[i].n > ranked[j].n
+		}
+		return ranked[i].conv < ranked[j].conv
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	var convs []string
+	for _, r := range ranked {
+		convs = append(convs, fmt.Sprintf("%s (%d)", r.conv, r.n))
+	}
+	return strings.Join(convs, ", ")
+}
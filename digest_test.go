@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendDigest(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.DigestTime = "18:00"
+	for i := 0; i < 3; i++ {
+		c.record("#general", "t", dispositionForwarded)
+	}
+	c.record("#random", "t", dispositionForwarded)
+	c.record("#random", "t", filteredBy("muted"))
+	c.trackAnswer("D1", "@alice", false)
+	c.trackAnswer("D2", "@bob", false)
+	c.trackAnswer("D2", "@bob", true)
+
+	day := time.Date(2024, 5, 6, 17, 59, 0, 0, time.Local)
+	c.sendDigest(day)
+	assert.Empty(t, h.sent)
+	c.sendDigest(day.Add(time.Minute))
+	c.sendDigest(day.Add(2 * time.Minute))
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Daily summary", h.sent[0].Title)
+		assert.Equal(t, "Forwarded: 4, filtered: 1\nTop conversations: #general (3), #random (1)\nUnanswered direct messages: @alice", h.sent[0].Message)
+	}
+	c.sendDigest(day.Add(24*time.Hour + time.Minute))
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Forwarded: 0, filtered: 0\nUnanswered direct messages: @alice", h.sent[1].Message)
+	}
+}
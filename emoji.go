@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// emojiTTL is how long the list of custom emoji is cached.
+const emojiTTL = time.Hour
+
+var emojiRe = regexp.MustCompile(`:([a-z0-9_+'-]+):`)
+
+// customEmojiURL returns the image URL of the custom emoji a message
+// consists of, or an empty string if the message has other content.
+func (c *Plugin) customEmojiURL(text string) string {
+	names := emojiRe.FindAllStringSubmatch(text, -1)
+	if len(names) == 0 || strings.TrimSpace(emojiRe.ReplaceAllString(text, "")) != "" {
+		return ""
+	}
+	return c.resolveEmoji(names[0][1])
+}
+
+// resolveEmoji returns the image URL of a custom emoji, following aliases.
+func (c *Plugin) resolveEmoji(name string) string {
+	c.mu.Lock()
+	stale := time.Since(c.emojiFetched) > emojiTTL
+	c.mu.Unlock()
+	if stale {
+		emoji, err := c.slackAPI().GetEmoji()
+		if err != nil && !isRestrictedError(err) {
+			c.logln(err)
+		} else {
+			c.mu.Lock()
+			c.emoji = emoji
+			c.emojiFetched = time.Now()
+			c.mu.Unlock()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < 5; i++ {
+		u, ok := c.emoji[name]
+		if !ok {
+			return ""
+		}
+		if !strings.HasPrefix(u, "alias:") {
+			return u
+		}
+		name = strings.TrimPrefix(u, "alias:")
+	}
+	return ""
+}
+
+// handleEmojiChanged notifies about a custom emoji added to the workspace
+// and adds it to the cache.
+func (c *Plugin) handleEmojiChanged(ev *slack.EmojiChangedEvent) {
+	if ev.SubType != "add" || ev.Name == "" {
+		return
+	}
+	c.mu.Lock()
+	if c.emoji != nil {
+		c.emoji[ev.Name] = ev.Value
+	}
+	enabled, priority := c.config.NotifyEmoji, c.config.EmojiPriority
+	c.mu.Unlock()
+	if !enabled {
+		return
+	}
+	c.notice("emoji", tr(c.locale(), "Emoji"), fmt.Sprintf(tr(c.locale(), "New custom emoji :%s:"), ev.Name), priority)
+}
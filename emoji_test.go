@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleEmojiChanged(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config), emoji: map[string]string{}}
+	c.handleEmojiChanged(&slack.EmojiChangedEvent{SubType: "add", Name: "partyparrot", Value: "https://emoji.example/partyparrot.gif"})
+	assert.Empty(t, h.sent)
+	assert.Equal(t, "https://emoji.example/partyparrot.gif", c.emoji["partyparrot"])
+
+	c.config.NotifyEmoji = true
+	c.handleEmojiChanged(&slack.EmojiChangedEvent{SubType: "remove", Names: []string{"partyparrot"}})
+	c.handleEmojiChanged(&slack.EmojiChangedEvent{SubType: "add", Name: "shipit", Value: "alias:squirrel"})
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "New custom emoji :shipit:", h.sent[0].Message)
+		assert.Equal(t, 1, h.sent[0].Priority)
+	}
+}
+
+func TestCustomEmojiURL(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"ok":true,"emoji":{"partyparrot":"https://emoji.example/partyparrot.gif","parrot":"alias:partyparrot"}}`)
+	}))
+	defer srv.Close()
+
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+
+	assert.Equal(t, "https://emoji.example/partyparrot.gif", c.customEmojiURL(":partyparrot:"))
+	assert.Equal(t, "https://emoji.example/partyparrot.gif", c.customEmojiURL(" :parrot: "))
+	assert.Empty(t, c.customEmojiURL("look :parrot:"))
+	assert.Empty(t, c.customEmojiURL(":smile:"))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// escalation is a notification to repeat if its message is still unread.
+type escalation struct {
+	channel string
+	ts      string
+	conv    string
+	due     time.Time
+	msg     plugin.Message
+}
+
+// watchUnread schedules the escalation of a notified direct message or
+// mention posted at ts in channel, labeled conv, if EscalateAfter is set.
+func (c *Plugin) watchUnread(channel, ts, conv string, msg plugin.Message, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.EscalateAfter <= 0 || ts == "" {
+		return
+	}
+	c.escalations = append(c.escalations, escalation{channel: channel, ts: ts, conv: conv, due: now.Add(c.config.EscalateAfter), msg: msg})
+}
+
+// watchSent schedules the escalation of a sent message that forward marked
+// with the notify extra as a direct message or mention.
+func (c *Plugin) watchSent(conv string, msg plugin.Message, now time.Time) {
+	ns, _ := msg.Extras["slack::message"].(map[string]interface{})
+	if ns["notify"] == nil {
+		return
+	}
+	channel, _ := ns["channel"].(string)
+	ts, _ := ns["ts"].(string)
+	c.watchUnread(channel, ts, conv, msg, now)
+}
+
+// withoutNotify returns a copy of extras without the notify extra, so that
+// an escalation is not watched again.
+func withoutNotify(extras map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(extras))
+	for k, v := range extras {
+		copied[k] = v
+	}
+	if ns, ok := extras["slack::message"].(map[string]interface{}); ok {
+		message := make(map[string]interface{}, len(ns))
+		for k, v := range ns {
+			if k != "notify" {
+				message[k] = v
+			}
+		}
+		copied["slack::message"] = message
+	}
+	return copied
+}
+
+// escalate notifies again with EscalatePriority about the watched messages
+// that are due and have not been read in Slack, as told by the read cursor
+// of their conversation.
+func (c *Plugin) escalate(now time.Time) {
+	c.mu.Lock()
+	var due []escalation
+	pending := c.escalations[:0]
+	for _, e := range c.escalations {
+		if now.Before(e.due) {
+			pending = append(pending, e)
+		} else {
+			due = append(due, e)
+		}
+	}
+	c.escalations = pending
+	priority, l := c.config.EscalatePriority, c.config.Locale
+	c.mu.Unlock()
+	for _, e := range due {
+		channel, err := c.slackAPI().GetConversationInfo(e.channel, false)
+		if err != nil {
+			c.logln(err)
+			continue
+		}
+		if !unreadSince(channel.LastRead, e.ts) {
+			continue
+		}
+		msg := e.msg
+		msg.Title = tr(l, "Still unread") + ": " + msg.Title
+		msg.Priority = priority
+		msg.Extras = withoutNotify(msg.Extras)
+		c.emit(e.conv, msg)
+	}
+}
+
+// unreadSince reports whether the message posted at ts lies after the read
+// cursor lastRead.
+func unreadSince(lastRead, ts string) bool {
+	read, err := strconv.ParseFloat(lastRead, 64)
+	if err != nil {
+		return true
+	}
+	posted, err := strconv.ParseFloat(ts, 64)
+	return err == nil && posted > read
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":true,"channel":{"id":"D1","last_read":"100.000200"}}`)
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.config.EscalateAfter = 10 * time.Minute
+	c.api, _ = c.config.client()
+	now := time.Now()
+	c.watchUnread("D1", "100.000100", "@alice", plugin.Message{Title: "read", Priority: 5}, now)
+	c.watchUnread("D1", "100.000300", "@alice", plugin.Message{Title: "unread", Priority: 5}, now)
+
+	c.escalate(now.Add(5 * time.Minute))
+	assert.Empty(t, h.sent)
+	c.escalate(now.Add(10 * time.Minute))
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Still unread: unread", h.sent[0].Title)
+		assert.Equal(t, 8, h.sent[0].Priority)
+	}
+	assert.Empty(t, c.escalations)
+}
+
+func TestWatchSent(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.EscalateAfter = 10 * time.Minute
+	mention := plugin.Message{Title: "mention", Priority: 5}
+	setExtra(&mention, "slack::message", "channel", "C1")
+	setExtra(&mention, "slack::message", "ts", "100.000100")
+	setExtra(&mention, "slack::message", "notify", "mention")
+
+	// A mention held back by the flood limit is not escalated.
+	c.config.FloodLimit, c.config.FloodWindow = 1, time.Hour
+	c.deliver(&slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}}, "#general", plugin.Message{Title: "other"})
+	c.deliver(&slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}}, "#general", mention)
+	assert.Empty(t, c.escalations)
+
+	c.emit("#general", mention)
+	if assert.Len(t, c.escalations, 1) {
+		assert.Equal(t, "100.000100", c.escalations[0].ts)
+		// The escalation itself is not watched again.
+		assert.Nil(t, withoutNotify(c.escalations[0].msg.Extras)["slack::message"].(map[string]interface{})["notify"])
+	}
+}
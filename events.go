@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	// The slack library reports events it does not know as unmarshalling
+	// errors, so the events the plugin reacts to are registered here.
+	slack.EventMapping["tokens_revoked"] = tokensRevokedEvent{}
+	slack.EventMapping["shared_channel_invite_received"] = sharedChannelInviteEvent{}
+}
+
+// tokensRevokedEvent is sent when tokens of the app have been revoked.
+type tokensRevokedEvent struct {
+	Tokens struct {
+		OAuth []string `json:"oauth"`
+		Bot   []string `json:"bot"`
+	} `json:"tokens"`
+}
+
+// sharedChannelInviteEvent is sent when the user is invited to a Slack Connect channel.
+type sharedChannelInviteEvent struct {
+	Invite struct {
+		InvitingUser struct {
+			Name     string `json:"name"`
+			RealName string `json:"real_name"`
+		} `json:"inviting_user"`
+		InvitingTeam struct {
+			Name string `json:"name"`
+		} `json:"inviting_team"`
+	} `json:"invite"`
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+}
+
+// isAuthError reports whether err returned by the Slack Web API means that
+// the token can no longer be used.
+func isAuthError(err error) bool {
+	switch err.Error() {
+	case "invalid_auth", "not_authed", "account_inactive", "token_revoked", "token_expired":
+		return true
+	}
+	return false
+}
+
+// recoverMessage decodes a message event the slack library failed to
+// unmarshal, which it does for messages with block types it does not know,
+// as sent by most apps. The blocks are returned separately.
+func recoverMessage(ev *slack.UnmarshallingErrorEvent) (*slack.MessageEvent, json.RawMessage, bool) {
+	s := ev.Error()
+	if !strings.HasPrefix(s, `RTM Error: Could not unmarshall event "message": `) {
+		return nil, nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s[strings.Index(s, "{"):]), &fields); err != nil {
+		return nil, nil, false
+	}
+	blocks := fields["blocks"]
+	delete(fields, "blocks")
+	for _, key := range []string{"message", "previous_message"} {
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(fields[key], &nested) != nil || nested == nil {
+			continue
+		}
+		delete(nested, "blocks")
+		fields[key], _ = json.Marshal(nested)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, false
+	}
+	var msg slack.MessageEvent
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, nil, false
+	}
+	return &msg, blocks, true
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	// snippetLines is the number of lines of a snippet shown in its preview.
+	snippetLines = 15
+	// snippetBytes limits how much of a snippet is downloaded for its preview.
+	snippetBytes = 16 << 10
+)
+
+var errPreviewFull = errors.New("preview complete")
+
+// previewBuffer is a buffer that refuses writes beyond snippetBytes, which
+// aborts the download of large snippets. It does not embed bytes.Buffer so
+// that io.Copy cannot bypass the limit through ReadFrom.
+type previewBuffer struct {
+	buf bytes.Buffer
+}
+
+func (b *previewBuffer) Write(p []byte) (int, error) {
+	if n := snippetBytes - b.buf.Len(); len(p) > n {
+		b.buf.Write(p[:n])
+		return n, errPreviewFull
+	}
+	return b.buf.Write(p)
+}
+
+func (b *previewBuffer) String() string {
+	return b.buf.String()
+}
+
+// fileText describes the files shared with a message. Text snippets are
+// shown with their first lines and a permalink, audio and video clips with
+// their length and a permalink, other files by their titles. thumb is the
+// thumbnail URL of a video clip.
+func (c *Plugin) fileText(files []slack.File, plain bool) (text, thumb string) {
+	var titles, lines []string
+	for _, file := range files {
+		if file.Mode == "snippet" {
+			lines = append(lines, c.snippetPreview(file, plain))
+			continue
+		}
+		if strings.HasPrefix(file.Mimetype, "audio/") || strings.HasPrefix(file.Mimetype, "video/") {
+			clip, err := c.fetchClip(file.ID)
+			if err != nil {
+				c.logln(err)
+			} else if s := clip.describe(c.locale()); s != "" {
+				lines = append(lines, s+"\n"+file.Permalink)
+				if thumb == "" {
+					thumb = clip.ThumbVideo
+				}
+				continue
+			}
+		}
+		titles = append(titles, file.Title)
+	}
+	if len(titles) != 0 {
+		lines = append(lines, tr(c.locale(), "Files")+": "+strings.Join(titles, ", "))
+	}
+	return strings.Join(lines, "\n"), thumb
+}
+
+// clip holds the details of an audio or video file the slack library does not decode.
+type clip struct {
+	Subtype    string `json:"subtype"`
+	DurationMS int64  `json:"duration_ms"`
+	ThumbVideo string `json:"thumb_video"`
+}
+
+// fetchClip reads the clip details of a file with files.info.
+func (c *Plugin) fetchClip(id string) (*clip, error) {
+	var resp struct {
+		File clip `json:"file"`
+	}
+	if err := c.call("files.info", url.Values{"file": {id}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.File, nil
+}
+
+// describe returns e.g. "sent a 0:42 audio clip", or an empty string for
+// files that are no clips recorded in Slack.
+func (cl *clip) describe(locale string) string {
+	var format string
+	switch cl.Subtype {
+	case "slack_audio":
+		format = "sent a %s audio clip"
+	case "slack_video":
+		format = "sent a %s video clip"
+	default:
+		return ""
+	}
+	d := time.Duration(cl.DurationMS) * time.Millisecond / time.Second
+	return fmt.Sprintf(tr(locale, format), fmt.Sprintf("%d:%02d", d/60, d%60))
+}
+
+// snippetPreview renders the title, first lines and permalink of a snippet.
+func (c *Plugin) snippetPreview(file slack.File, plain bool) string {
+	var buf previewBuffer
+	var content string
+	more := false
+	switch err := c.slackAPI().GetFile(file.URLPrivateDownload, &buf); err {
+	case nil:
+		content = buf.String()
+	case errPreviewFull:
+		content, more = buf.String(), true
+	default:
+		c.logln(err)
+		content, more = file.Preview, file.LinesMore > 0
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > snippetLines {
+		lines, more = lines[:snippetLines], true
+	}
+	preview := strings.Join(lines, "\n")
+	if more {
+		preview += "\n…"
+	}
+	if !plain {
+		preview = "```\n" + preview + "\n```"
+	}
+	s := file.Title + "\n" + preview
+	if file.Permalink != "" {
+		s += "\n" + file.Permalink
+	}
+	return s
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewBufferLimit(t *testing.T) {
+	var buf previewBuffer
+	_, err := io.Copy(&buf, strings.NewReader(strings.Repeat("x", snippetBytes+1)))
+	assert.Equal(t, errPreviewFull, err)
+	assert.Len(t, buf.String(), snippetBytes)
+}
+
+func TestClipDescribe(t *testing.T) {
+	assert.Equal(t, "sent a 0:42 audio clip", (&clip{Subtype: "slack_audio", DurationMS: 42600}).describe("en"))
+	assert.Equal(t, "hat einen 2:05 langen Videoclip gesendet", (&clip{Subtype: "slack_video", DurationMS: 125000}).describe("de"))
+	assert.Equal(t, "", (&clip{DurationMS: 1000}).describe("en"))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// floodGate limits the number of notifications sent within a sliding window.
+type floodGate struct {
+	mu         sync.Mutex
+	sent       []time.Time
+	suppressed int
+}
+
+// allow reports whether another notification may be sent at now and records it if so.
+// A limit of zero disables the gate.
+func (g *floodGate) allow(now time.Time, limit int, window time.Duration) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if limit <= 0 {
+		return true
+	}
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(g.sent) && !g.sent[i].After(cutoff) {
+		i++
+	}
+	g.sent = g.sent[i:]
+	if len(g.sent) >= limit {
+		g.suppressed++
+		return false
+	}
+	g.sent = append(g.sent, now)
+	return true
+}
+
+// takeSuppressed returns the number of suppressed notifications and resets it.
+func (g *floodGate) takeSuppressed() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := g.suppressed
+	g.suppressed = 0
+	return n
+}
+
+// suppressedOnce reports whether exactly one notification has been suppressed,
+// i.e. whether a flood has just started.
+func (g *floodGate) suppressedOnce() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.suppressed == 1
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloodGate(t *testing.T) {
+	var g floodGate
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(t, g.allow(start, 3, time.Minute))
+	}
+	assert.False(t, g.allow(start.Add(time.Second), 3, time.Minute))
+	assert.False(t, g.allow(start.Add(2*time.Second), 3, time.Minute))
+	assert.True(t, g.allow(start.Add(time.Minute+time.Second), 3, time.Minute))
+	assert.Equal(t, 2, g.takeSuppressed())
+	assert.Equal(t, 0, g.takeSuppressed())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRe      = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+	codeBlockRe = regexp.MustCompile("```([\\s\\S]*?)```")
+	inlineRe    = regexp.MustCompile("(^|[\\s(])([*_~`])([^\\s*_~`](?:[^*_~`\\n]*[^\\s*_~`])?)([*_~`])($|[\\s).,!?:;])")
+	quoteRe     = regexp.MustCompile(`(?m)^(?:>|&gt;) ?`)
+	spacesRe    = regexp.MustCompile(`[ \t]{2,}`)
+	trailingRe  = regexp.MustCompile(`(?m)[ \t]+$`)
+)
+
+// unescaper decodes the only entities Slack escapes in message text.
+var unescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// unescape decodes the HTML entities of Slack message text. It has to be
+// applied after parsing mentions and links, whose brackets are not escaped.
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+// plainText strips Slack's mrkdwn, link syntax and emoji shortcodes from s.
+func plainText(s string) string {
+	s = codeBlockRe.ReplaceAllString(s, "$1")
+	s = linkRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := linkRe.FindStringSubmatch(m)
+		target, label := parts[1], parts[2]
+		switch {
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return string(target[0]) + strings.TrimPrefix(label, string(target[0]))
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			if label != "" {
+				return label
+			}
+			return "@" + strings.SplitN(target[1:], "^", 2)[0]
+		case label == "" || label == target:
+			return strings.TrimPrefix(target, "mailto:")
+		}
+		return label + " (" + target + ")"
+	})
+	for {
+		stripped := inlineRe.ReplaceAllStringFunc(s, func(m string) string {
+			parts := inlineRe.FindStringSubmatch(m)
+			if parts[2] != parts[4] {
+				return m
+			}
+			return parts[1] + parts[3] + parts[5]
+		})
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = quoteRe.ReplaceAllString(s, "")
+	s = emojiRe.ReplaceAllString(s, "")
+	s = spacesRe.ReplaceAllString(s, " ")
+	s = trailingRe.ReplaceAllString(s, "")
+	return strings.TrimSpace(s)
+}
+
+// shorten cuts s to at most n runes, ending it with "…" if cut.
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// collapseLines keeps the first maxLines non-empty lines of s, all of them
+// if maxLines is 0, and joins them with " ⏎ " if join is set.
+func collapseLines(s string, maxLines int, join bool) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	truncated := maxLines > 0 && len(lines) > maxLines
+	if truncated {
+		lines = lines[:maxLines]
+	}
+	sep := "\n"
+	if join {
+		sep = " ⏎ "
+	}
+	s = strings.Join(lines, sep)
+	if truncated {
+		s += " …"
+	}
+	return s
+}
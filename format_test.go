@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainText(t *testing.T) {
+	assert.Equal(t, "bold italic strike code", plainText("*bold* _italic_ ~strike~ `code`"))
+	assert.Equal(t, "see docs (https://example.com) or https://example.org",
+		plainText("see <https://example.com|docs> or <https://example.org>"))
+	assert.Equal(t, "@Alice in #general, @here", plainText("<@Alice> in <#C123|general>, <!here>"))
+	assert.Equal(t, "ship it", plainText("ship it :rocket: :tada:"))
+	assert.Equal(t, "quoted\nfoo()", plainText("> quoted\n```foo()```"))
+	assert.Equal(t, "2*3*4", plainText("2*3*4"))
+}
+
+func TestCollapseLines(t *testing.T) {
+	s := "first\n\nsecond \nthird"
+	assert.Equal(t, "first ⏎ second ⏎ third", collapseLines(s, 0, true))
+	assert.Equal(t, "first\nsecond …", collapseLines(s, 2, false))
+	assert.Equal(t, "first …", collapseLines(s, 1, true))
+}
+
+func TestUnescape(t *testing.T) {
+	assert.Equal(t, "a < b && c > d", unescape("a &lt; b &amp;&amp; c &gt; d"))
+	assert.Equal(t, "&lt; stays escaped", unescape("&amp;lt; stays escaped"))
+	assert.Equal(t, "&copy;", unescape("&copy;"))
+}
+
+func TestShorten(t *testing.T) {
+	assert.Equal(t, "deploy", shorten("deploy", 6))
+	assert.Equal(t, "depl…", shorten("deploy failed", 5))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	botParsers = append(botParsers, botParser{
+		name:  "github",
+		bots:  []string{"github"},
+		parse: parseGitHub,
+	})
+}
+
+// githubURLRe matches links to pull requests, issues, commits and releases,
+// capturing the repository, the kind of object and its number or ID.
+var githubURLRe = regexp.MustCompile(`https://github\.com/([\w.-]+/[\w.-]+)/(pull|issues|commit|releases/tag)/([\w.-]+)`)
+
+// githubActorRe finds who did something in a GitHub notification.
+var githubActorRe = regexp.MustCompile(`\bby @?([\w-]+)`)
+
+// githubActions are the actions recognized in GitHub notifications, most
+// specific first, with the name used in their kind.
+var githubActions = []struct{ text, name string }{
+	{"changes requested", "changes_requested"},
+	{"requested changes", "changes_requested"},
+	{"review requested", "review_requested"},
+	{"approved", "approved"},
+	{"merged", "merged"},
+	{"reopened", "reopened"},
+	{"opened", "opened"},
+	{"closed", "closed"},
+	{"commented", "commented"},
+	{"comment", "commented"},
+	{"pushed", "pushed"},
+	{"released", "released"},
+	{"published", "released"},
+}
+
+// parseGitHub turns a notification of the GitHub app into a compact one
+// like "PR #123 approved by alice — owner/repo". Its kind is the object
+// and action, e.g. "pr_approved", "issue_opened" or "commit_pushed".
+func parseGitHub(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage {
+	texts := []string{msg.Text, renderBlocks(blocks)}
+	var subject string
+	for _, att := range msg.Attachments {
+		texts = append(texts, att.Pretext, att.Title, att.TitleLink, att.Text, att.Fallback, att.AuthorName)
+		if subject == "" {
+			subject = att.Title
+		}
+	}
+	all := strings.Join(texts, "\n")
+	m := githubURLRe.FindStringSubmatch(all)
+	if m == nil {
+		return nil
+	}
+	repo, object, id := m[1], m[2], m[3]
+	var what, kind string
+	switch object {
+	case "pull":
+		what, kind = "PR #"+id, "pr"
+	case "issues":
+		what, kind = "Issue #"+id, "issue"
+	case "commit":
+		if len(id) > 7 {
+			id = id[:7]
+		}
+		what, kind = "Commit "+id, "commit"
+	default:
+		what, kind = "Release "+id, "release"
+	}
+	lower := strings.ToLower(all)
+	action := ""
+	for _, a := range githubActions {
+		if strings.Contains(lower, a.text) {
+			action = a.name
+			break
+		}
+	}
+	text := what
+	if action != "" {
+		kind += "_" + action
+		text += " " + strings.Replace(action, "_", " ", -1)
+	}
+	if a := githubActorRe.FindStringSubmatch(all); a != nil {
+		text += " by " + a[1]
+	} else if len(msg.Attachments) != 0 && msg.Attachments[0].AuthorName != "" {
+		text += " by " + msg.Attachments[0].AuthorName
+	}
+	text = fmt.Sprintf("%s — %s", text, repo)
+	if subject = strings.TrimSpace(plainText(subject)); subject != "" {
+		text += "\n" + subject
+	}
+	return &parsedMessage{kind: kind, text: text, url: m[0]}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGitHub(t *testing.T) {
+	c := &Plugin{config: &Config{Parsers: map[string]ParserConfig{"github": {Priorities: map[string]int{"pr_approved": 7}}}}}
+	github := &sender{id: "B1", name: "GitHub", bot: true}
+	msg := &slack.Msg{Attachments: []slack.Attachment{{
+		Pretext:   "Pull request approved by alice",
+		Title:     "#123 Fix the flaky login test",
+		TitleLink: "https://github.com/acme/web/pull/123",
+	}}}
+	name, p, pc := c.parseBotMessage(github, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "github", name)
+		assert.Equal(t, "pr_approved", p.kind)
+		assert.Equal(t, "PR #123 approved by alice — acme/web\n#123 Fix the flaky login test", p.text)
+		assert.Equal(t, "https://github.com/acme/web/pull/123", p.url)
+		assert.Equal(t, 7, pc.priority(p, 5))
+	}
+
+	msg = &slack.Msg{Text: "Issue opened by <https://github.com/bob|bob>: <https://github.com/acme/web/issues/7|Crash on start>"}
+	_, p, _ = c.parseBotMessage(github, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "issue_opened", p.kind)
+	}
+
+	_, p, _ = c.parseBotMessage(github, &slack.Msg{Text: "Subscribed to acme/web"}, nil)
+	assert.Nil(t, p)
+}
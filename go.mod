@@ -1,11 +1,13 @@
 module github.com/gotify/plugin-template
 
 require (
-	github.com/gorilla/websocket v1.4.0 // indirect
+	github.com/gin-gonic/gin v1.3.0
+	github.com/gorilla/websocket v1.4.0
 	github.com/gotify/plugin-api v1.0.0
 	github.com/nlopes/slack v0.6.0
 	github.com/pkg/errors v0.8.1 // indirect
 	github.com/stretchr/testify v1.3.0
+	gopkg.in/yaml.v2 v2.2.2
 )
 
 go 1.13
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// gotifyTimeout bounds requests to the gotify REST API.
+const gotifyTimeout = 10 * time.Second
+
+// gotifyApplication is an application as returned by the gotify REST API.
+type gotifyApplication struct {
+	ID          int    `json:"id,omitempty"`
+	Token       string `json:"token,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// gotifyMessage is a message as accepted by the gotify REST API.
+type gotifyMessage struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// gotifyRequest calls the gotify REST API at GotifyURL authorized with
+// token, sending body and decoding the response into out if not nil.
+func (conf *Config) gotifyRequest(method, path, token string, body, out interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(conf.GotifyURL, "/")+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", token)
+	resp, err := (&http.Client{Timeout: gotifyTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gotify %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// appName names the gotify application of a conversation.
+func appName(conv string) string {
+	return "Slack " + conv
+}
+
+// appToken returns the token of the gotify application of the conversation
+// labeled conv, creating the application on first use.
+func (c *Plugin) appToken(config *Config, conv string) (string, error) {
+	c.mu.Lock()
+	token, ok := c.apps[conv]
+	c.mu.Unlock()
+	if ok {
+		return token, nil
+	}
+	name := appName(conv)
+	var apps []gotifyApplication
+	if err := config.gotifyRequest("GET", "/application", config.GotifyClientToken, nil, &apps); err != nil {
+		return "", err
+	}
+	for _, app := range apps {
+		if app.Name == name {
+			token = app.Token
+		}
+	}
+	if token == "" {
+		app := gotifyApplication{Name: name, Description: fmt.Sprintf(tr(config.Locale, "Messages from %s in Slack"), conv)}
+		if err := config.gotifyRequest("POST", "/application", config.GotifyClientToken, app, &app); err != nil {
+			return "", err
+		}
+		token = app.Token
+	}
+	c.mu.Lock()
+	if c.apps == nil {
+		c.apps = make(map[string]string)
+	}
+	c.apps[conv] = token
+	c.mu.Unlock()
+	return token, nil
+}
+
+// route sends msg from the conversation labeled conv to the gotify
+// application mapped to it in AppTokens or, if ChannelApps is set, to the
+// conversation's own application. Other messages and messages that could
+// not be delivered that way go to the plugin's application.
+func (c *Plugin) route(conv string, msg plugin.Message) {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	if config == nil || config.GotifyURL == "" {
+		c.send(msg)
+		return
+	}
+	token, ok := config.AppTokens[conv]
+	var err error
+	if !ok && config.ChannelApps && config.GotifyClientToken != "" {
+		token, err = c.appToken(config, conv)
+		ok = err == nil
+	}
+	if ok {
+		err = config.gotifyRequest("POST", "/message", token, gotifyMessage{msg.Title, msg.Message, msg.Priority, msg.Extras}, nil)
+		if err == nil {
+			c.recordLatency(msg, time.Now())
+			return
+		}
+	}
+	if err != nil {
+		c.logln(err)
+	}
+	c.send(msg)
+}
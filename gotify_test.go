@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteChannelApps(t *testing.T) {
+	var created []string
+	var posted []gotifyMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /application":
+			assert.Equal(t, "client", r.Header.Get("X-Gotify-Key"))
+			json.NewEncoder(w).Encode([]gotifyApplication{{ID: 1, Token: "existing", Name: "Slack #general"}})
+		case "POST /application":
+			var app gotifyApplication
+			json.NewDecoder(r.Body).Decode(&app)
+			created = append(created, app.Name)
+			app.Token = "created"
+			json.NewEncoder(w).Encode(app)
+		case "POST /message":
+			var msg gotifyMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			msg.Title = r.Header.Get("X-Gotify-Key") + ": " + msg.Title
+			posted = append(posted, msg)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.ChannelApps = true
+	c.config.GotifyURL = srv.URL + "/"
+	c.config.GotifyClientToken = "client"
+
+	c.route("#general", plugin.Message{Title: "a", Priority: 5})
+	c.route("#random", plugin.Message{Title: "b", Priority: 5})
+	c.route("#random", plugin.Message{Title: "c", Priority: 5})
+	assert.Equal(t, []string{"Slack #random"}, created)
+	if assert.Len(t, posted, 3) {
+		assert.Equal(t, "existing: a", posted[0].Title)
+		assert.Equal(t, "created: c", posted[2].Title)
+	}
+	assert.Empty(t, h.sent)
+
+	c.config.GotifyURL = "http://127.0.0.1:0"
+	c.apps = nil
+	c.route("#general", plugin.Message{Title: "fallback", Priority: 5})
+	assert.Len(t, h.sent, 1)
+}
+
+func TestRouteAppTokens(t *testing.T) {
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message", r.URL.Path)
+		tokens = append(tokens, r.Header.Get("X-Gotify-Key"))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.GotifyURL = srv.URL
+	c.config.AppTokens = map[string]string{"#alerts": "alerts", "@alice": "alice"}
+
+	c.route("#alerts", plugin.Message{Title: "a"})
+	c.route("@alice", plugin.Message{Title: "b"})
+	c.route("#general", plugin.Message{Title: "c"})
+	assert.Equal(t, []string{"alerts", "alice"}, tokens)
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "c", h.sent[0].Title)
+	}
+
+	c.config.AppTokens = map[string]string{"general": "x"}
+	c.config.SlackToken = "xoxp-test"
+	assert.Error(t, (&Plugin{}).ValidateAndSetConfig(c.config))
+}
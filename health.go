@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// defaultHealthCheckInterval is how often the token is checked if HealthCheckInterval is not set.
+const defaultHealthCheckInterval = 15 * time.Minute
+
+// healthCheckInterval returns how often the connection to Slack is checked.
+func (c *Plugin) healthCheckInterval() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.HealthCheckInterval <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return c.config.HealthCheckInterval
+}
+
+// checkHealth calls auth.test, independent of message traffic. Errors
+// meaning the token can no longer be used are returned, so that the
+// connection is given up. Other errors, e.g. of the network, are warned
+// about once until a check succeeds again.
+func (c *Plugin) checkHealth() error {
+	_, err := c.slackAPI().AuthTest()
+	c.mu.Lock()
+	c.lastHealthCheck = time.Now()
+	warned := c.healthWarned
+	c.healthWarned = err != nil
+	if err != nil {
+		c.fault = err
+	} else if warned {
+		c.fault = nil
+	}
+	l, priority := c.config.Locale, c.config.WarningPriority
+	c.mu.Unlock()
+	switch {
+	case err != nil && isAuthError(err):
+		return err
+	case err != nil && !warned:
+		c.logln(err)
+		c.send(plugin.Message{
+			Title:    c.statusTitle(tr(l, "Health check failed")),
+			Message:  fmt.Sprintf(tr(l, "Slack could not be reached: %s. Messages may not be forwarded until it recovers."), err),
+			Priority: priority,
+		})
+	case err != nil:
+		c.logln(err)
+	case warned:
+		c.send(plugin.Message{
+			Title:    c.statusTitle(tr(l, "Health check succeeded")),
+			Message:  tr(l, "Slack can be reached again."),
+			Priority: priority,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusOK
+	body := `{"ok":true,"user_id":"U1"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config), team: "Acme"}
+	c.config.APIURL = srv.URL
+	c.config.TitleSeparator = " - "
+	c.config.WarningPriority = 4
+	c.api, _ = c.config.client()
+
+	assert.NoError(t, c.checkHealth())
+	assert.Empty(t, h.sent)
+
+	status = http.StatusInternalServerError
+	assert.NoError(t, c.checkHealth())
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack - Acme - Health check failed", h.sent[0].Title)
+		assert.Equal(t, 4, h.sent[0].Priority)
+	}
+	assert.Error(t, c.fault)
+	assert.NoError(t, c.checkHealth())
+	assert.Len(t, h.sent, 1)
+
+	status = http.StatusOK
+	assert.NoError(t, c.checkHealth())
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Slack - Acme - Health check succeeded", h.sent[1].Title)
+		assert.Equal(t, 4, h.sent[1].Priority)
+	}
+	assert.NoError(t, c.fault)
+	assert.NoError(t, c.checkHealth())
+	assert.Len(t, h.sent, 2)
+
+	body = `{"ok":false,"error":"invalid_auth"}`
+	assert.Error(t, c.checkHealth())
+	assert.Len(t, h.sent, 2)
+}
@@ -0,0 +1,115 @@
+package main
+
+// translations maps a locale to translations of the plugin's English strings.
+// To add a language, add a table keyed by the English text; missing entries
+// fall back to English.
+var translations = map[string]map[string]string{
+	"de": {
+		"[Edit]":               "[Bearbeitet]",
+		"Thread":               "Thread",
+		"(%d messages)":        "(%d Nachrichten)",
+		"Files":                "Dateien",
+		"sent a %s audio clip": "hat einen %s langen Audioclip gesendet",
+		"sent a %s video clip": "hat einen %s langen Videoclip gesendet",
+		"Status":               "Status",
+		"Plugin enabled":       "Plugin aktiviert",
+		"Valid API token":      "Gültiger API-Token",
+		"yes":                  "ja",
+		"no":                   "nein",
+		"Tip: You can get your API token [here](%s).": "Tipp: Deinen API-Token bekommst du [hier](%s).",
+		"Connection":                  "Verbindung",
+		"ok":                          "ok",
+		"error":                       "Fehler",
+		"Connection lost":             "Verbindung verloren",
+		"connecting":                  "verbinde",
+		"waiting to reconnect":        "warte auf erneuten Verbindungsversuch",
+		"waiting for a new config":    "warte auf eine neue Konfiguration",
+		"stopping":                    "wird beendet",
+		"disabled":                    "deaktiviert",
+		"Last health check":           "Letzte Prüfung",
+		"Health check failed":         "Prüfung fehlgeschlagen",
+		"Health check succeeded":      "Prüfung erfolgreich",
+		"Slack can be reached again.": "Slack ist wieder erreichbar.",
+		"Slack could not be reached: %s. Messages may not be forwarded until it recovers.": "Slack war nicht erreichbar: %s. Bis dahin werden eventuell keine Nachrichten weitergeleitet.",
+		"No more Slack messages will be forwarded: %s. Please check the Slack API token.":  "Es werden keine Slack-Nachrichten mehr weitergeleitet: %s. Bitte prüfe den Slack-API-Token.",
+		"+%d Slack messages suppressed, see Slack":                                         "+%d Slack-Nachrichten unterdrückt, siehe Slack",
+		"Recent messages":            "Letzte Nachrichten",
+		"No messages yet.":           "Noch keine Nachrichten.",
+		"Time":                       "Zeit",
+		"Title":                      "Titel",
+		"Disposition":                "Verbleib",
+		"Activity":                   "Aktivität",
+		"Conversation":               "Unterhaltung",
+		"Last forwarded":             "Zuletzt weitergeleitet",
+		"%s invited you to #%s":      "%s hat dich in #%s eingeladen",
+		"%s (%s) invited you to #%s": "%s (%s) hat dich in #%s eingeladen",
+		"You were added to #%s":      "Du wurdest zu #%s hinzugefügt",
+		"You were removed from #%s":  "Du wurdest aus #%s entfernt",
+		"%s edited %s":               "%s hat %s bearbeitet",
+		"%s mentioned you in %s":     "%s hat dich in %s erwähnt",
+		"Slash command":              "Slash-Befehl",
+		"Debug events":               "Debug-Ereignisse",
+		"Mute for an hour":           "Eine Stunde stummschalten",
+		"Dry run: nothing is sent to gotify, the recent messages show what would have been forwarded.": "Probelauf: Es wird nichts an gotify gesendet, die letzten Nachrichten zeigen, was weitergeleitet worden wäre.",
+		"The config was imported.": "Die Konfiguration wurde importiert.",
+		"Send a test message":      "Testnachricht senden",
+		"A test message was passed through the filters and formatting; the plugin page lists what happened to it.": "Eine Testnachricht wurde durch Filter und Formatierung geschickt; die Plugin-Seite zeigt, was mit ihr passiert ist.",
+		"+%d replies in thread '%s'":    "+%d Antworten im Thread '%s'",
+		"Daily summary":                 "Tageszusammenfassung",
+		"Forwarded: %d, filtered: %d":   "Weitergeleitet: %d, gefiltert: %d",
+		"Top conversations":             "Aktivste Unterhaltungen",
+		"Unanswered direct messages":    "Unbeantwortete Direktnachrichten",
+		"Unread":                        "Ungelesen",
+		"Slack: %d unread, %d mentions": "Slack: %d ungelesen, %d Erwähnungen",
+		"Reminder":                      "Erinnerung",
+		"Celebration":                   "Feier",
+		"%s changed #%s topic to: %s":   "%s hat das Thema von #%s geändert: %s",
+		"%s changed #%s purpose to: %s": "%s hat den Zweck von #%s geändert: %s",
+		"Emoji":                         "Emoji",
+		"New custom emoji :%s:":         "Neues eigenes Emoji :%s:",
+		"%s just joined the workspace":  "%s ist dem Workspace beigetreten",
+		"Still unread":                  "Noch ungelesen",
+		"Only in this summary":          "Nur in dieser Zusammenfassung",
+		"Messages from %s in Slack":     "Nachrichten aus %s in Slack",
+		"Latency":                       "Latenz",
+		"API rate limit":                "API-Ratenlimit",
+		"%s approaches Slack's limit of %d calls a minute. Consider caching or polling fewer conversations.": "%s nähert sich Slacks Limit von %d Aufrufen pro Minute. Caching oder das Abfragen weniger Unterhaltungen kann helfen.",
+		"API calls":                          "API-Aufrufe",
+		"No API calls in the last minute.":   "Keine API-Aufrufe in der letzten Minute.",
+		"Method":                             "Methode",
+		"Calls per minute":                   "Aufrufe pro Minute",
+		"Limit":                              "Limit",
+		"+%d more messages in %s, see Slack": "+%d weitere Nachrichten in %s, siehe Slack",
+		"Overload":                           "Überlastung",
+		"+%d Slack messages dropped under load, see Slack": "+%d Slack-Nachrichten wegen Überlastung verworfen, siehe Slack",
+		"Event queue full": "Ereigniswarteschlange voll",
+		"Slack events arrive faster than they can be handled, so the oldest waiting messages are dropped. See the plugin's page for the queue's load.": "Slack-Ereignisse kommen schneller an, als sie verarbeitet werden können, daher werden die ältesten wartenden Nachrichten verworfen. Die Auslastung der Warteschlange steht auf der Seite des Plugins.",
+		"Slack events arrive faster than they can be handled, so messages are delayed. See the plugin's page for the queue's load.":                    "Slack-Ereignisse kommen schneller an, als sie verarbeitet werden können, daher werden Nachrichten verzögert. Die Auslastung der Warteschlange steht auf der Seite des Plugins.",
+		"Event queue": "Ereigniswarteschlange",
+		"%d waiting, peak %d, full %d times, %d dropped": "%d wartend, Spitze %d, %d-mal voll, %d verworfen",
+		"last":                        "zuletzt",
+		"Muted the channel until %s.": "Der Kanal ist bis %s stummgeschaltet.",
+		"Internal error":              "Interner Fehler",
+		"An internal error occurred: %v. Forwarding continues, see the log for details.": "Ein interner Fehler ist aufgetreten: %v. Die Weiterleitung läuft weiter, Details stehen im Log.",
+		"No events yet.": "Noch keine Ereignisse.",
+		"Event":          "Ereignis",
+		"The events are available as JSON at `%s`, passing AdminSecret in the `X-Admin-Secret` header.":                                     "Die Ereignisse sind als JSON unter `%s` abrufbar, wenn AdminSecret im Header `X-Admin-Secret` übergeben wird.",
+		"Create a slash command `/gotify` in your Slack app with the request URL `%s` and set `SigningSecret` to the app's signing secret.": "Lege in deiner Slack-App einen Slash-Befehl `/gotify` mit der Request-URL `%s` an und setze `SigningSecret` auf das Signing Secret der App.",
+	},
+}
+
+// tr translates s into the given locale.
+func tr(locale, s string) string {
+	if t, ok := translations[locale][s]; ok {
+		return t
+	}
+	return s
+}
+
+// trBool translates a boolean into "yes" or "no".
+func trBool(locale string, b bool) string {
+	if b {
+		return tr(locale, "yes")
+	}
+	return tr(locale, "no")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTr(t *testing.T) {
+	assert.Equal(t, "Verbindung verloren", tr("de", "Connection lost"))
+	assert.Equal(t, "ja", trBool("de", true))
+	assert.Equal(t, "no", trBool("", false))
+
+	assert.Equal(t, "Connection lost", tr("", "Connection lost"))
+	assert.Equal(t, "Connection lost", tr("fr", "Connection lost"))
+	assert.Equal(t, "not translated", tr("de", "not translated"))
+}
+
+func TestTranslationsKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for locale, table := range translations {
+		for en, s := range table {
+			assert.Equal(t, verbs.FindAllString(en, -1), verbs.FindAllString(s, -1), "%s: %q", locale, en)
+		}
+	}
+}
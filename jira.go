@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	botParsers = append(botParsers, botParser{
+		name:  "jira",
+		bots:  []string{"jira"},
+		parse: parseJira,
+	})
+}
+
+// jiraPriority is the priority of Jira notifications about issues assigned
+// to or mentioning the user.
+const jiraPriority = 8
+
+var (
+	// jiraIssueRe matches links to Jira issues, capturing the issue key.
+	jiraIssueRe = regexp.MustCompile(`https://[\w.-]+/browse/([A-Z][A-Z0-9_]+-\d+)`)
+	// jiraStatusRe finds the new status of a transitioned issue.
+	jiraStatusRe = regexp.MustCompile(`(?i)(?:changed (?:the )?status(?: from .+?)? to|moved (?:.+? )?to|transitioned (?:.+? )?to)\s*[*_"]*([^*_"\n]+?)[*_"]*\s*(?:$|\n)`)
+	// jiraAssigneeRe finds the assignee of an issue.
+	jiraAssigneeRe = regexp.MustCompile(`(?i)\bassignee:?\s*[*_]*([^*_\n]+?)[*_]*\s*(?:$|\n)`)
+)
+
+// parseJira turns a notification of the Jira Cloud app into a compact one
+// like "PROJ-42 moved to In Review (assignee: me)". Its kind is
+// "transitioned", "assigned", "commented", "created" or "updated".
+// Issues assigned to or mentioning the user get a higher priority.
+func parseJira(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage {
+	texts := []string{msg.Text, renderBlocks(blocks)}
+	var summary string
+	for _, att := range msg.Attachments {
+		texts = append(texts, att.Pretext, att.Title, att.TitleLink, attachmentText(att))
+		if summary == "" {
+			summary = att.Title
+		}
+	}
+	all := strings.Join(texts, "\n")
+	m := jiraIssueRe.FindStringSubmatch(all)
+	if m == nil {
+		return nil
+	}
+	key := m[1]
+	p := &parsedMessage{url: m[0], text: key}
+	lower := strings.ToLower(all)
+	switch s := jiraStatusRe.FindStringSubmatch(all); {
+	case s != nil:
+		p.kind = "transitioned"
+		p.text += " moved to " + strings.TrimSpace(s[1])
+	case strings.Contains(lower, "assigned"):
+		p.kind = "assigned"
+		p.text += " assigned"
+	case strings.Contains(lower, "comment"):
+		p.kind = "commented"
+		p.text += " commented"
+	case strings.Contains(lower, "created"):
+		p.kind = "created"
+		p.text += " created"
+	default:
+		p.kind = "updated"
+		p.text += " updated"
+	}
+	mine := c.mentionsMe(all)
+	if a := jiraAssigneeRe.FindStringSubmatch(all); a != nil {
+		assignee := strings.TrimSpace(a[1])
+		if c.isMe(assignee) {
+			assignee = "me"
+			mine = true
+		}
+		p.text += " (assignee: " + assignee + ")"
+	}
+	if mine {
+		p.priority = jiraPriority
+	}
+	summary = strings.TrimSpace(strings.TrimPrefix(plainText(summary), key))
+	if summary = strings.TrimLeft(summary, ": "); summary != "" {
+		p.text += "\n" + summary
+	}
+	return p
+}
+
+// isMe reports whether name is the real or display name of the user.
+func (c *Plugin) isMe(name string) bool {
+	uid := c.userID()
+	if uid == "" || name == "" {
+		return false
+	}
+	if strings.Contains(name, "<@"+uid) {
+		return true
+	}
+	me, err := c.userInfo(uid)
+	if err != nil || me == nil {
+		return false
+	}
+	return strings.EqualFold(name, me.RealName) || strings.EqualFold(name, me.Profile.DisplayName)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJira(t *testing.T) {
+	c := &Plugin{uid: "U1", config: &Config{}, users: map[string]cachedUser{
+		"U1": {user: &slack.User{ID: "U1", RealName: "Jane Doe"}, fetched: time.Now()},
+	}}
+	jira := &sender{id: "B1", name: "Jira Cloud", bot: true}
+	msg := &slack.Msg{Attachments: []slack.Attachment{{
+		Pretext:   "Alice changed status from To Do to *In Review*",
+		Title:     "PROJ-42: Speed up the login",
+		TitleLink: "https://acme.atlassian.net/browse/PROJ-42",
+		Fields:    []slack.AttachmentField{{Title: "Assignee", Value: "Jane Doe"}},
+	}}}
+	name, p, pc := c.parseBotMessage(jira, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "jira", name)
+		assert.Equal(t, "transitioned", p.kind)
+		assert.Equal(t, "PROJ-42 moved to In Review (assignee: me)\nSpeed up the login", p.text)
+		assert.Equal(t, "https://acme.atlassian.net/browse/PROJ-42", p.url)
+		assert.Equal(t, 8, pc.priority(p, 5))
+	}
+
+	msg = &slack.Msg{Text: "Bob commented on <https://acme.atlassian.net/browse/OPS-7|OPS-7>"}
+	_, p, pc = c.parseBotMessage(jira, msg, nil)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "OPS-7 commented", p.text)
+		assert.Equal(t, 5, pc.priority(p, 5))
+	}
+}
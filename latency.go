@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+const (
+	// maxLatencySamples bounds the number of latencies kept for the statistics.
+	maxLatencySamples = 1000
+	// maxLatencySample excludes messages from the statistics that were held
+	// back on purpose, e.g. by quiet hours, or that are edits of old messages.
+	maxLatencySample = time.Hour
+)
+
+// latencies keeps the latest delays between posting messages in Slack and
+// gotify accepting them.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// add records a latency, replacing the oldest one if there are enough.
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < maxLatencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % maxLatencySamples
+}
+
+// percentiles returns the given percentiles of the recorded latencies,
+// false if there are none.
+func (l *latencies) percentiles(ps ...float64) ([]time.Duration, bool) {
+	l.mu.Lock()
+	sorted := append([]time.Duration(nil), l.samples...)
+	l.mu.Unlock()
+	if len(sorted) == 0 {
+		return nil, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		result[i] = sorted[int(p*float64(len(sorted)-1)+0.5)]
+	}
+	return result, true
+}
+
+// recordLatency records the delay between posting msg in Slack and now,
+// when gotify accepted it. Messages not originating from a Slack message
+// are ignored.
+func (c *Plugin) recordLatency(msg plugin.Message, now time.Time) {
+	ns, _ := msg.Extras["slack::message"].(map[string]interface{})
+	ts, _ := ns["ts"].(string)
+	posted, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return
+	}
+	d := now.Sub(time.Unix(0, int64(posted*float64(time.Second))))
+	if d < 0 || d > maxLatencySample {
+		return
+	}
+	c.latency.add(d)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	c := &Plugin{}
+	_, ok := c.latency.percentiles(0.5)
+	assert.False(t, ok)
+
+	now := time.Unix(1500000100, 0)
+	for i := 1; i <= 100; i++ {
+		var msg plugin.Message
+		setExtra(&msg, "slack::message", "ts", timestamp(now.Add(-time.Duration(i)*100*time.Millisecond)))
+		c.recordLatency(msg, now)
+	}
+	var old plugin.Message
+	setExtra(&old, "slack::message", "ts", timestamp(now.Add(-2*time.Hour)))
+	c.recordLatency(old, now)
+	c.recordLatency(plugin.Message{Title: "notice"}, now)
+
+	ps, ok := c.latency.percentiles(0.5, 0.95)
+	assert.True(t, ok)
+	assert.InDelta(t, 5*time.Second, ps[0], float64(200*time.Millisecond))
+	assert.InDelta(t, 9500*time.Millisecond, ps[1], float64(200*time.Millisecond))
+	assert.Len(t, c.latency.samples, 100)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// rotatingFile is a log file that is rotated when it exceeds maxSize bytes,
+// keeping the given number of old files as path.1, path.2 and so on.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	keep    int
+	f       *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64, keep int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, keep: keep}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the old files by one, dropping the oldest, and starts a new file.
+// r.mu must be held.
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.keep))
+	for i := r.keep - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.keep > 0 {
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// logln logs to gotify's log and, if configured, the plugin's log file.
+func (c *Plugin) logln(v ...interface{}) {
+	log.Println(v...)
+	c.logMu.Lock()
+	l := c.logger
+	c.logMu.Unlock()
+	if l != nil {
+		l.Println(v...)
+	}
+}
+
+// setLogFile starts logging to the configured log file, replacing the previous one.
+func (c *Plugin) setLogFile(config *Config) error {
+	var (
+		file   *rotatingFile
+		logger *log.Logger
+	)
+	if config.LogFile != "" {
+		var err error
+		file, err = openRotatingFile(config.LogFile, int64(config.LogMaxSize)<<20, config.LogRetention)
+		if err != nil {
+			return err
+		}
+		logger = log.New(file, "", log.LstdFlags)
+	}
+	c.logMu.Lock()
+	old := c.logFile
+	c.logFile, c.logger = file, logger
+	c.logMu.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// broadcastMentions are the channel-wide mentions that notify everyone.
+var broadcastMentions = []string{"<!channel", "<!here", "<!everyone"}
+
+// subteamRe matches mentions of user groups, capturing the group's ID.
+var subteamRe = regexp.MustCompile(`<!subteam\^([A-Z0-9]+)`)
+
+// mentionText renders a mention of user in a forwarded message as set by
+// MentionFormat, naming the user as set by NameSource.
+func (c *Plugin) mentionText(user *slack.User) string {
+	return c.formatMention(c.userName(user))
+}
+
+// formatMention renders a mention of the user called name as set by
+// MentionFormat.
+func (c *Plugin) formatMention(name string) string {
+	c.mu.Lock()
+	format := c.config.MentionFormat
+	c.mu.Unlock()
+	switch format {
+	case "at":
+		return "@" + name
+	case "bold":
+		return "**@" + name + "**"
+	case "name":
+		return name
+	case "none":
+		return ""
+	}
+	return "<@" + name + ">"
+}
+
+// replaceMention renders the raw user mention m, e.g. "<@U12345>", with
+// mentionText. A failed lookup is retried once; if it still fails, the
+// mention's label or the user's ID is rendered instead.
+func (c *Plugin) replaceMention(m string) string {
+	id := strings.Trim(m, "<@>")
+	var label string
+	if i := strings.Index(id, "|"); i >= 0 {
+		id, label = id[:i], id[i+1:]
+	}
+	user, err := c.userInfo(id)
+	if err != nil && !isRestrictedError(err) {
+		user, err = c.userInfo(id)
+	}
+	if err != nil || user == nil {
+		if err != nil && !isRestrictedError(err) {
+			c.logln(err)
+		}
+		if label != "" {
+			return c.formatMention(label)
+		}
+		return c.formatMention(id)
+	}
+	return c.mentionText(user)
+}
+
+// mentionsMe reports whether the raw message text mentions the user,
+// either personally, through one of the user's groups or with a
+// channel-wide mention.
+func (c *Plugin) mentionsMe(text string) bool {
+	if uid := c.userID(); uid != "" && strings.Contains(text, "<@"+uid) {
+		return true
+	}
+	for _, m := range broadcastMentions {
+		if strings.Contains(text, m) {
+			return true
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range subteamRe.FindAllStringSubmatch(text, -1) {
+		if c.groups[m[1]] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+const (
+	// maxPending bounds the number of messages kept for retrying.
+	maxPending     = 100
+	minSendBackoff = 5 * time.Second
+	maxSendBackoff = 5 * time.Minute
+)
+
+// outbox keeps messages gotify failed to accept for retrying them in order.
+type outbox struct {
+	mu        sync.Mutex
+	pending   []plugin.Message
+	backoff   time.Duration
+	scheduled bool
+}
+
+// send passes msg to gotify. If gotify fails to accept it, no message handler
+// is set yet, or earlier messages are still waiting to be retried, msg is
+// queued and retried with backoff.
+func (c *Plugin) send(msg plugin.Message) {
+	c.outbox.mu.Lock()
+	handler := c.msgHandler
+	if handler == nil || len(c.outbox.pending) != 0 {
+		c.enqueue(msg)
+		c.outbox.mu.Unlock()
+		return
+	}
+	c.outbox.mu.Unlock()
+	if err := handler.SendMessage(msg); err != nil {
+		c.logln(err)
+		c.outbox.mu.Lock()
+		c.enqueue(msg)
+		c.outbox.mu.Unlock()
+		return
+	}
+	c.recordLatency(msg, time.Now())
+}
+
+// emit forwards msg from the conversation labeled conv and records it.
+// In dry-run mode it only records the message and the priority it would have had.
+func (c *Plugin) emit(conv string, msg plugin.Message) {
+	c.redact(&msg)
+	c.mu.Lock()
+	sep, max := c.config.TitleSeparator, c.config.MaxTitleLength
+	c.mu.Unlock()
+	if sep == "" {
+		sep = " | "
+	}
+	msg.Title = capTitle(msg.Title, sep, max)
+	if c.dryRun() {
+		c.logln("dry run:", msg.Title, "priority", msg.Priority)
+		c.record(conv, msg.Title, fmt.Sprintf("%s (priority %d)", dispositionDryRun, msg.Priority))
+		return
+	}
+	c.route(conv, c.withActionLinks(msg))
+	c.mirror(conv, msg)
+	c.publish(conv, msg)
+	c.watchSent(conv, msg, time.Now())
+	c.record(conv, msg.Title, dispositionForwarded)
+}
+
+// dryRun reports whether messages are only evaluated, not sent.
+func (c *Plugin) dryRun() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config != nil && c.config.DryRun
+}
+
+// enqueue adds msg to the outbox, dropping the oldest message if it is full.
+// c.outbox.mu must be held.
+func (c *Plugin) enqueue(msg plugin.Message) {
+	if len(c.outbox.pending) >= maxPending {
+		c.logln("retry queue full, dropping oldest message")
+		c.outbox.pending = c.outbox.pending[1:]
+	}
+	c.outbox.pending = append(c.outbox.pending, msg)
+	if !c.outbox.scheduled && c.msgHandler != nil {
+		if c.outbox.backoff == 0 {
+			c.outbox.backoff = minSendBackoff
+		}
+		c.outbox.scheduled = true
+		time.AfterFunc(c.outbox.backoff, func() { c.safely(c.retrySends) })
+	}
+}
+
+// retrySends resends the queued messages until gotify fails again.
+func (c *Plugin) retrySends() {
+	c.outbox.mu.Lock()
+	defer c.outbox.mu.Unlock()
+	c.outbox.scheduled = false
+	for len(c.outbox.pending) != 0 && c.msgHandler != nil {
+		if err := c.msgHandler.SendMessage(c.outbox.pending[0]); err != nil {
+			c.logln(err)
+			c.outbox.backoff *= 2
+			if c.outbox.backoff < minSendBackoff {
+				c.outbox.backoff = minSendBackoff
+			}
+			if c.outbox.backoff > maxSendBackoff {
+				c.outbox.backoff = maxSendBackoff
+			}
+			c.outbox.scheduled = true
+			time.AfterFunc(c.outbox.backoff, func() { c.safely(c.retrySends) })
+			return
+		}
+		c.recordLatency(c.outbox.pending[0], time.Now())
+		c.outbox.pending = c.outbox.pending[1:]
+	}
+	c.outbox.backoff = 0
+}
+
+// SetMessageHandler implements plugin.Messenger.
+// Messages composed before the handler was set are sent now.
+func (c *Plugin) SetMessageHandler(h plugin.MessageHandler) {
+	c.outbox.mu.Lock()
+	c.msgHandler = h
+	flush := len(c.outbox.pending) != 0 && !c.outbox.scheduled
+	c.outbox.mu.Unlock()
+	if flush {
+		go c.safely(c.retrySends)
+	}
+}
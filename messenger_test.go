@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHandler struct {
+	fail bool
+	sent []plugin.Message
+}
+
+func (h *fakeHandler) SendMessage(msg plugin.Message) error {
+	if h.fail {
+		return errors.New("gotify unavailable")
+	}
+	h.sent = append(h.sent, msg)
+	return nil
+}
+
+func TestSendRetriesInOrder(t *testing.T) {
+	h := &fakeHandler{fail: true}
+	c := &Plugin{msgHandler: h}
+	c.send(plugin.Message{Message: "first"})
+	h.fail = false
+	c.send(plugin.Message{Message: "second"})
+	assert.Empty(t, h.sent)
+	c.retrySends()
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "first", h.sent[0].Message)
+		assert.Equal(t, "second", h.sent[1].Message)
+	}
+}
+
+func TestSendBuffersUntilHandlerIsSet(t *testing.T) {
+	c := &Plugin{}
+	c.send(plugin.Message{Message: "early"})
+	h := &fakeHandler{}
+	c.outbox.mu.Lock()
+	c.msgHandler = h
+	c.outbox.mu.Unlock()
+	c.retrySends()
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "early", h.sent[0].Message)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+)
+
+// configVersion is the version of the config schema. Bump it along with a
+// new entry in migrations whenever a change needs older configs adapted.
+const configVersion = 1
+
+// migrations upgrade a config of version i to version i+1.
+var migrations = []func(*Config){
+	// Version 1 replaced Backfill with the Startup modes.
+	func(conf *Config) {
+		if conf.Backfill && conf.Startup == "" {
+			conf.Startup = "unread"
+		}
+		conf.Backfill = false
+	},
+}
+
+// migrate upgrades config to the current schema version in place. Configs
+// stored before versioning are decoded onto the default config and thus
+// carry its version, so they are recognized by their deprecated fields.
+func migrate(config *Config) error {
+	if config.Backfill {
+		config.Version = 0
+	}
+	if config.Version > configVersion {
+		return fmt.Errorf("the config has version %d, but this plugin only supports up to version %d", config.Version, configVersion)
+	}
+	for ; config.Version < configVersion; config.Version++ {
+		migrations[config.Version](config)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	conf := (&Plugin{}).DefaultConfig().(*Config)
+	unchanged := *conf
+	assert.NoError(t, migrate(conf))
+	assert.Equal(t, &unchanged, conf)
+
+	conf.Backfill = true
+	conf.Startup = ""
+	assert.NoError(t, migrate(conf))
+	assert.Equal(t, configVersion, conf.Version)
+	assert.Equal(t, "unread", conf.Startup)
+	assert.False(t, conf.Backfill)
+
+	conf.Startup = "live"
+	assert.NoError(t, migrate(conf))
+	assert.Equal(t, "live", conf.Startup)
+
+	conf.Version = configVersion + 1
+	assert.Error(t, migrate(conf))
+}
+
+func TestApplyConfigMigrates(t *testing.T) {
+	c := &Plugin{}
+	conf := c.DefaultConfig().(*Config)
+	conf.Backfill = true
+	conf.Startup = ""
+	assert.NoError(t, c.ValidateAndSetConfig(conf))
+	assert.Equal(t, configVersion, c.config.Version)
+	assert.Equal(t, "unread", c.config.Startup)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+// mirrorTimeout bounds posting an event to MirrorURL.
+const mirrorTimeout = 10 * time.Second
+
+// mirroredEvent is the JSON posted to MirrorURL for each forwarded message.
+type mirroredEvent struct {
+	Type         string    `json:"type"`
+	Conversation string    `json:"conversation"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	Priority     int       `json:"priority"`
+	URL          string    `json:"url,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// clickURL returns the URL opened when clicking the notification of msg.
+func clickURL(msg plugin.Message) string {
+	ns, _ := msg.Extras["client::notification"].(map[string]interface{})
+	click, _ := ns["click"].(map[string]string)
+	return click["url"]
+}
+
+// signature returns the hex encoded HMAC-SHA256 of body keyed with secret.
+func signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// mirror posts msg from the conversation labeled conv to MirrorURL, if set,
+// without waiting for the response.
+func (c *Plugin) mirror(conv string, msg plugin.Message) {
+	c.mu.Lock()
+	target, secret := c.config.MirrorURL, c.config.MirrorSecret
+	c.mu.Unlock()
+	if target == "" {
+		return
+	}
+	ev := mirroredEvent{
+		Type:         "message",
+		Conversation: conv,
+		Title:        msg.Title,
+		Message:      msg.Message,
+		Priority:     msg.Priority,
+		URL:          clickURL(msg),
+		Time:         time.Now(),
+	}
+	go c.safely(func() {
+		if err := postMirror(target, secret, ev); err != nil {
+			c.logln(err)
+		}
+	})
+}
+
+// postMirror posts ev to target. With a secret, the body is signed in the
+// X-Signature-256 header as "sha256=" followed by its hex encoded HMAC.
+func postMirror(target, secret string, ev mirroredEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Signature-256", "sha256="+signature(secret, body))
+	}
+	resp, err := (&http.Client{Timeout: mirrorTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("mirroring to %s: %s", target, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostMirror(t *testing.T) {
+	var got mirroredEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+signature("secret", body), r.Header.Get("X-Signature-256"))
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	msg := plugin.Message{Title: "Slack | #general | alice", Message: "hi", Priority: 5}
+	setExtra(&msg, "client::notification", "click", map[string]string{"url": "slack://channel?id=C1"})
+	ev := mirroredEvent{Type: "message", Conversation: "#general", Title: msg.Title, Message: msg.Message, Priority: msg.Priority, URL: clickURL(msg), Time: time.Now()}
+	assert.NoError(t, postMirror(srv.URL, "secret", ev))
+	assert.Equal(t, "#general", got.Conversation)
+	assert.Equal(t, "slack://channel?id=C1", got.URL)
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	assert.Error(t, postMirror(srv.URL, "", ev))
+}
+
+func TestSignature(t *testing.T) {
+	// HMAC-SHA256 of the body with the key "secret", as computed by
+	// `openssl dgst -sha256 -hmac secret`.
+	assert.Equal(t, "8c45932b8c65734ebe0eb4b5af8481d13a5b5e8aaf00a1ffeedcff13ba37969a", signature("secret", []byte(`{"type":"message"}`)))
+}
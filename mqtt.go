@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotify/plugin-api"
+)
+
+const (
+	// mqttTimeout bounds publishing a message to the MQTT broker.
+	mqttTimeout = 10 * time.Second
+	// defaultMQTTTopic is the topic template if MQTTTopic is not set.
+	defaultMQTTTopic = "slack/{{.Conversation}}"
+)
+
+// mqttClient keeps one connection to the MQTT broker per plugin instance,
+// so that publishes are serialized and brokers do not drop the session of
+// another client with the same ID.
+type mqttClient struct {
+	mu sync.Mutex
+	// id is the client ID, unique per instance.
+	id string
+	// broker identifies the settings conn was established with.
+	broker string
+	conn   net.Conn
+}
+
+// mqttTopicData is available to the MQTT topic template.
+type mqttTopicData struct {
+	// Conversation is the channel or user name.
+	Conversation string
+	Direct       bool
+	Priority     int
+}
+
+// mqttTopic renders the topic template for a message from the conversation
+// labeled conv. MQTT wildcards are replaced, as they may not be published to.
+func mqttTopic(tmpl, conv string, priority int) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultMQTTTopic
+	}
+	t, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	data := mqttTopicData{Conversation: strings.TrimLeft(conv, "#@"), Direct: strings.HasPrefix(conv, "@"), Priority: priority}
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.NewReplacer("#", "_", "+", "_").Replace(b.String()), nil
+}
+
+// validateMQTT checks the broker URL and topic template.
+func (conf *Config) validateMQTT() error {
+	if conf.MQTTBroker == "" {
+		return nil
+	}
+	u, err := url.Parse(conf.MQTTBroker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid MQTTBroker %q", conf.MQTTBroker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return fmt.Errorf("invalid MQTTBroker %q, expected tcp:// or ssl://", conf.MQTTBroker)
+	}
+	_, err = mqttTopic(conf.MQTTTopic, "#general", 0)
+	return err
+}
+
+// publish publishes msg from the conversation labeled conv to the MQTT
+// broker, if configured, without waiting for the broker.
+func (c *Plugin) publish(conv string, msg plugin.Message) {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	if config.MQTTBroker == "" {
+		return
+	}
+	topic, err := mqttTopic(config.MQTTTopic, conv, msg.Priority)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	payload, err := json.Marshal(mirroredEvent{
+		Type:         "message",
+		Conversation: conv,
+		Title:        msg.Title,
+		Message:      msg.Message,
+		Priority:     msg.Priority,
+		URL:          clickURL(msg),
+		Time:         time.Now(),
+	})
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	go c.safely(func() {
+		if err := c.mqtt.publish(config, topic, payload); err != nil {
+			c.logln("mqtt:", err)
+		}
+	})
+}
+
+// publish publishes payload to topic with QoS 0, connecting to the broker
+// of conf first if needed. A broken connection is reestablished once.
+func (m *mqttClient) publish(conf *Config, topic string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	broker := conf.MQTTBroker + "\x00" + conf.MQTTUsername + "\x00" + conf.MQTTPassword
+	if m.conn != nil && m.broker != broker {
+		m.conn.Close()
+		m.conn = nil
+	}
+	if m.id == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		m.id = "gotify-slack-" + hex.EncodeToString(id)
+	}
+	packet := mqttPacket(0x30, append(mqttString(topic), payload...))
+	for retry := 0; ; retry++ {
+		if m.conn == nil {
+			conn, err := conf.mqttConnect(m.id)
+			if err != nil {
+				return err
+			}
+			m.conn, m.broker = conn, broker
+		}
+		m.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+		_, err := m.conn.Write(packet)
+		if err == nil {
+			return nil
+		}
+		m.conn.Close()
+		m.conn = nil
+		if retry > 0 {
+			return err
+		}
+	}
+}
+
+// close disconnects from the broker.
+func (m *mqttClient) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return
+	}
+	m.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+	m.conn.Write(mqttPacket(0xe0, nil))
+	m.conn.Close()
+	m.conn = nil
+}
+
+// mqttConnect connects to MQTTBroker as clientID, using MQTT 3.1.1. Keep
+// alive is disabled, so the broker does not drop idle connections.
+func (conf *Config) mqttConnect(clientID string) (net.Conn, error) {
+	u, err := url.Parse(conf.MQTTBroker)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "1883")
+	}
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	switch u.Scheme {
+	case "ssl", "tls", "mqtts":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "8883")
+		}
+		var tc *tls.Config
+		tc, err = conf.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tc)
+	default:
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(mqttTimeout))
+
+	var flags byte = 0x02 // clean session
+	connect := append(mqttString("MQTT"), 4, 0, 0, 0)
+	connect = append(connect, mqttString(clientID)...)
+	if conf.MQTTUsername != "" {
+		flags |= 0x80
+		connect = append(connect, mqttString(conf.MQTTUsername)...)
+		if conf.MQTTPassword != "" {
+			flags |= 0x40
+			connect = append(connect, mqttString(conf.MQTTPassword)...)
+		}
+	}
+	connect[7] = flags
+	connack := make([]byte, 4)
+	if _, err := conn.Write(mqttPacket(0x10, connect)); err == nil {
+		_, err = io.ReadFull(conn, connack)
+	}
+	switch {
+	case err != nil:
+	case connack[0] != 0x20:
+		err = errors.New("unexpected reply to connect")
+	case connack[3] != 0:
+		err = fmt.Errorf("connection refused with code %d", connack[3])
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// mqttString encodes s as a length-prefixed MQTT string.
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+// mqttPacket frames body as an MQTT control packet of the given type.
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQTTTopic(t *testing.T) {
+	topic, err := mqttTopic("", "#general", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "slack/general", topic)
+	topic, err = mqttTopic("home/{{if .Direct}}dm{{else}}channel{{end}}/{{.Conversation}}", "@al+ice", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "home/dm/al_ice", topic)
+}
+
+func TestMQTTPublish(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		header := make([]byte, 2)
+		io.ReadFull(conn, header)
+		connect := make([]byte, header[1])
+		io.ReadFull(conn, connect)
+		conn.Write([]byte{0x20, 2, 0, 0})
+		b, _ := ioutil.ReadAll(conn)
+		received <- append(connect, b...)
+	}()
+
+	config := &Config{MQTTBroker: "tcp://" + ln.Addr().String(), MQTTUsername: "user", MQTTPassword: "pass"}
+	assert.NoError(t, config.validateMQTT())
+	var m mqttClient
+	assert.NoError(t, m.publish(config, "slack/general", []byte("{}")))
+	assert.NoError(t, m.publish(config, "slack/random", []byte("[]")))
+	m.close()
+	b := <-received
+	assert.Equal(t, byte(0xc2), b[7])
+	assert.Contains(t, string(b), "gotify-slack-")
+	assert.Contains(t, string(b), "user")
+	publish := append(mqttPacket(0x30, append(mqttString("slack/general"), "{}"...)), mqttPacket(0x30, append(mqttString("slack/random"), "[]"...))...)
+	assert.True(t, bytes.HasSuffix(b, append(publish, 0xe0, 0)))
+
+	config.MQTTBroker = "http://broker"
+	assert.Error(t, config.validateMQTT())
+}
+
+func TestMQTTConnectTLSFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	config := &Config{MQTTBroker: "ssl://" + addr}
+	var m mqttClient
+	assert.Error(t, m.publish(config, "slack/general", []byte("{}")))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// notice sends a notification about a Slack event other than a message,
+// e.g. an invitation. conv labels the conversation the event concerns.
+// Quiet hours, profiles and the flood limit apply as for messages.
+func (c *Plugin) notice(conv, channelName, text string, priority int) {
+	title := c.title(titleParts{team: c.teamName(""), channel: channelName})
+	now := time.Now()
+	c.mu.Lock()
+	quiet := c.quietHours().Contains(now)
+	c.mu.Unlock()
+	if quiet {
+		c.record(conv, title, filteredBy(string(ruleQuietHours)))
+		return
+	}
+	priority, reason := c.applyProfile(priority, false, now)
+	if reason != "" {
+		c.record(conv, title, filteredBy(reason))
+		return
+	}
+	if !c.allowFlood(now) {
+		c.record(conv, title, filteredBy("flood limit"))
+		return
+	}
+	c.emit(conv, plugin.Message{Title: title, Message: text, Priority: priority})
+}
+
+// handleInvitation notifies about the user having been invited to a
+// channel, given the channel_join message announcing it.
+func (c *Plugin) handleInvitation(msg *slack.Msg) {
+	c.mu.Lock()
+	enabled := c.config.NotifyInvitations
+	c.mu.Unlock()
+	if !enabled {
+		return
+	}
+	channel, err := c.conversationInfo(msg.Channel)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	inviter, err := c.resolveSender(&slack.Msg{User: msg.Inviter})
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	name := c.channelName(channel)
+	c.notice("#"+name, name, fmt.Sprintf(tr(c.locale(), "%s invited you to #%s"), inviter.name, name), c.defaultPriority())
+}
+
+// handleSharedInvite notifies about an invitation to a Slack Connect channel.
+func (c *Plugin) handleSharedInvite(ev *sharedChannelInviteEvent) {
+	c.mu.Lock()
+	enabled := c.config.NotifyInvitations
+	c.mu.Unlock()
+	if !enabled {
+		return
+	}
+	inviter := ev.Invite.InvitingUser.RealName
+	if inviter == "" {
+		inviter = ev.Invite.InvitingUser.Name
+	}
+	text := fmt.Sprintf(tr(c.locale(), "%s (%s) invited you to #%s"), inviter, ev.Invite.InvitingTeam.Name, ev.Channel.Name)
+	c.notice("#"+ev.Channel.Name, ev.Channel.Name, text, c.defaultPriority())
+}
+
+// handleMembership notifies about the user having been added to or removed
+// from a channel. Joins with an inviter are covered by invitation notices.
+func (c *Plugin) handleMembership(user, channelID, inviter string, joined bool) {
+	c.mu.Lock()
+	enabled, priority := c.config.NotifyMembership, c.config.MembershipPriority
+	invitations := c.config.NotifyInvitations
+	c.mu.Unlock()
+	if !enabled || user != c.userID() || (joined && inviter != "" && invitations) {
+		return
+	}
+	name := channelID
+	if channel, err := c.conversationInfo(channelID); err == nil {
+		name = c.channelName(channel)
+	} else {
+		c.logln(err)
+	}
+	text := tr(c.locale(), "You were removed from #%s")
+	if joined {
+		text = tr(c.locale(), "You were added to #%s")
+	}
+	c.notice("#"+name, name, fmt.Sprintf(text, name), priority)
+}
+
+// handleTeamJoin notifies about a new member of the workspace.
+func (c *Plugin) handleTeamJoin(user *slack.User) {
+	c.mu.Lock()
+	enabled, priority := c.config.NotifyTeamJoin, c.config.TeamJoinPriority
+	c.mu.Unlock()
+	if !enabled || user.IsBot || user.Deleted {
+		return
+	}
+	name := c.userName(user)
+	c.notice("team", "", fmt.Sprintf(tr(c.locale(), "%s just joined the workspace"), name), priority)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTeamJoin(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.handleTeamJoin(&slack.User{Name: "bob", RealName: "Bob"})
+	assert.Empty(t, h.sent)
+
+	c.config.NotifyTeamJoin = true
+	c.handleTeamJoin(&slack.User{Name: "deploybot", IsBot: true})
+	c.handleTeamJoin(&slack.User{Name: "bob"})
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme", h.sent[0].Title)
+		assert.Equal(t, "bob just joined the workspace", h.sent[0].Message)
+		assert.Equal(t, 2, h.sent[0].Priority)
+	}
+}
+
+func TestHandleInvitation(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.users = map[string]cachedUser{"U2": {user: &slack.User{ID: "U2", RealName: "Alice"}, fetched: time.Now()}}
+	c.channels = map[string]cachedChannel{"C1": {channel: &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}, fetched: time.Now()}}
+	join := &slack.Msg{Type: "message", SubType: "channel_join", Channel: "C1", User: "U1", Inviter: "U2"}
+	c.handleInvitation(join)
+	c.config.NotifyInvitations = false
+	c.handleInvitation(join)
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme | ops", h.sent[0].Title)
+		assert.Equal(t, "Alice invited you to #ops", h.sent[0].Message)
+		assert.Equal(t, 5, h.sent[0].Priority)
+	}
+}
+
+func TestHandleMembership(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.channels = map[string]cachedChannel{"C1": {channel: &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}, fetched: time.Now()}}
+	c.handleMembership("U1", "C1", "", true)
+	assert.Empty(t, h.sent)
+
+	c.config.NotifyMembership = true
+	c.config.NotifyInvitations = true
+	c.handleMembership("U2", "C1", "", true)
+	c.handleMembership("U1", "C1", "U2", true)
+	assert.Empty(t, h.sent)
+
+	c.handleMembership("U1", "C1", "", true)
+	c.handleMembership("U1", "C1", "", false)
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Slack | Acme | ops", h.sent[0].Title)
+		assert.Equal(t, "You were added to #ops", h.sent[0].Message)
+		assert.Equal(t, 3, h.sent[0].Priority)
+		assert.Equal(t, "You were removed from #ops", h.sent[1].Message)
+	}
+
+	c.config.NotifyInvitations = false
+	c.handleMembership("U1", "C1", "U2", true)
+	if assert.Len(t, h.sent, 3) {
+		assert.Equal(t, "You were added to #ops", h.sent[2].Message)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"github.com/nlopes/slack"
+)
+
+// historyPageSize is the number of messages fetched per history call.
+const historyPageSize = 200
+
+// advanceOffset records ts as the last message handled in channel. It
+// reports false if the message has been handled already, e.g. before a
+// restart or while catching up.
+// Slack timestamps of the same length compare like the times they denote.
+func (c *Plugin) advanceOffset(channel, ts string) bool {
+	if channel == "" || ts == "" {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.offsets[channel]; ok && len(last) == len(ts) && ts <= last {
+		return false
+	}
+	if c.offsets == nil {
+		c.offsets = make(map[string]string)
+	}
+	c.offsets[channel] = ts
+	c.offsetsDirty = true
+	return true
+}
+
+// loadOffsets reads the persisted offsets unless they are loaded already.
+// Polling resumes from them.
+func (c *Plugin) loadOffsets() error {
+	c.mu.Lock()
+	loaded := c.offsets != nil
+	c.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	state, err := c.loadState()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offsets = make(map[string]string, len(state.Offsets))
+	for channel, ts := range state.Offsets {
+		c.offsets[channel] = ts
+		if _, ok := c.cursors[channel]; !ok {
+			if c.cursors == nil {
+				c.cursors = make(map[string]string)
+			}
+			c.cursors[channel] = ts
+		}
+	}
+	return nil
+}
+
+// saveOffsets persists the offsets if they changed since the last save.
+func (c *Plugin) saveOffsets() error {
+	c.mu.Lock()
+	if !c.offsetsDirty {
+		c.mu.Unlock()
+		return nil
+	}
+	offsets := make(map[string]string, len(c.offsets))
+	for channel, ts := range c.offsets {
+		offsets[channel] = ts
+	}
+	c.offsetsDirty = false
+	c.mu.Unlock()
+	return c.updateState(func(state *storedState) {
+		state.Offsets = offsets
+	})
+}
+
+// catchUp handles the messages posted since the persisted offsets, so that
+// messages posted while the plugin was down are not skipped.
+func (c *Plugin) catchUp() {
+	c.mu.Lock()
+	offsets := make(map[string]string, len(c.offsets))
+	for channel, ts := range c.offsets {
+		offsets[channel] = ts
+	}
+	c.mu.Unlock()
+	for channel, oldest := range offsets {
+		messages, err := c.history(channel, oldest)
+		if err != nil {
+			c.logln(err)
+			continue
+		}
+		for _, m := range messages {
+			m.Channel = channel
+			ev := &slack.MessageEvent{Msg: m.Msg, SubMessage: m.SubMessage}
+			c.handleMessage(ev, nil)
+		}
+	}
+}
+
+// history fetches all messages posted in channel after oldest, page by
+// page, and returns them oldest first.
+func (c *Plugin) history(channel, oldest string) ([]slack.Message, error) {
+	params := &slack.GetConversationHistoryParameters{ChannelID: channel, Oldest: oldest, Limit: historyPageSize}
+	var messages []slack.Message
+	for {
+		history, err := c.slackAPI().GetConversationHistory(params)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, history.Messages...)
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			break
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+	// The history lists the newest message first.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
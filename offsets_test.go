@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsets(t *testing.T) {
+	storage := &memoryStorage{}
+	c := &Plugin{}
+	c.SetStorageHandler(storage)
+	assert.NoError(t, c.loadOffsets())
+	assert.True(t, c.advanceOffset("C1", "1500000000.000200"))
+	assert.False(t, c.advanceOffset("C1", "1500000000.000200"))
+	assert.False(t, c.advanceOffset("C1", "1500000000.000100"))
+	assert.True(t, c.advanceOffset("C1", "1500000000.000300"))
+	assert.True(t, c.advanceOffset("D1", "1500000000.000100"))
+	assert.NoError(t, c.saveOffsets())
+	assert.False(t, c.offsetsDirty)
+
+	restarted := &Plugin{}
+	restarted.SetStorageHandler(storage)
+	assert.NoError(t, restarted.loadOffsets())
+	assert.Equal(t, map[string]string{"C1": "1500000000.000300", "D1": "1500000000.000100"}, restarted.offsets)
+	assert.Equal(t, restarted.offsets, restarted.cursors)
+	assert.False(t, restarted.advanceOffset("C1", "1500000000.000300"))
+	assert.True(t, restarted.advanceOffset("C1", "1500000000.000400"))
+}
+
+func TestHistoryPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1500000000.000100", r.FormValue("oldest"))
+		if r.FormValue("cursor") == "" {
+			fmt.Fprint(w, `{"ok":true,"has_more":true,"response_metadata":{"next_cursor":"page2"},"messages":[{"ts":"1500000000.000400"},{"ts":"1500000000.000300"}]}`)
+			return
+		}
+		assert.Equal(t, "page2", r.FormValue("cursor"))
+		fmt.Fprint(w, `{"ok":true,"messages":[{"ts":"1500000000.000200"}]}`)
+	}))
+	defer srv.Close()
+
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+	messages, err := c.history("C1", "1500000000.000100")
+	assert.NoError(t, err)
+	var ts []string
+	for _, m := range messages {
+		ts = append(ts, m.Timestamp)
+	}
+	assert.Equal(t, []string{"1500000000.000200", "1500000000.000300", "1500000000.000400"}, ts)
+}
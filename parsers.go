@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// ParserConfig configures a bot message parser.
+type ParserConfig struct {
+	// Disabled turns the parser off.
+	Disabled bool
+	// Bots lists further bot names or IDs whose messages the parser handles.
+	Bots []string
+	// Priorities maps the kinds of parsed messages, e.g. event types or
+	// statuses, to priorities.
+	Priorities map[string]int
+	// Drop lists the kinds of parsed messages not to forward.
+	Drop []string
+}
+
+// parsedMessage is a bot message a parser turned into a compact notification.
+type parsedMessage struct {
+	// kind is the event type or status, see ParserConfig.
+	kind string
+	// label prefixes the title, e.g. "[Triggered]".
+	label string
+	text  string
+	// priority is the priority the parser derived, zero if none.
+	priority int
+	// url is opened when the notification is clicked.
+	url string
+}
+
+// botParser parses messages of a kind of bot. It returns nil for messages
+// it does not handle.
+type botParser struct {
+	name string
+	// bots lists lower-case name fragments of the bots handled by default.
+	bots  []string
+	parse func(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage
+}
+
+// botParsers are the available parsers by the name used in Config.Parsers.
+var botParsers []botParser
+
+// handles reports whether the parser handles messages of the bot s.
+func (p botParser) handles(s *sender, extra []string) bool {
+	name := strings.ToLower(s.name)
+	for _, b := range p.bots {
+		if strings.Contains(name, b) {
+			return true
+		}
+	}
+	for _, b := range extra {
+		if b == s.id || strings.EqualFold(b, s.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBotMessage runs the parsers enabled for the bot s on its message.
+// It returns the name of the parser and the parsed message, or nil if no
+// parser handles it.
+func (c *Plugin) parseBotMessage(s *sender, msg *slack.Msg, blocks json.RawMessage) (string, *parsedMessage, *ParserConfig) {
+	if !s.bot {
+		return "", nil, nil
+	}
+	c.mu.Lock()
+	configs := c.config.Parsers
+	c.mu.Unlock()
+	for _, p := range botParsers {
+		pc := configs[p.name]
+		if pc.Disabled || !p.handles(s, pc.Bots) {
+			continue
+		}
+		if parsed := p.parse(c, msg, blocks); parsed != nil {
+			return p.name, parsed, &pc
+		}
+	}
+	return "", nil, nil
+}
+
+// priority returns the configured priority of the parsed message, its own
+// one or fallback.
+func (pc *ParserConfig) priority(parsed *parsedMessage, fallback int) int {
+	if p, ok := pc.Priorities[parsed.kind]; ok {
+		return p
+	}
+	if parsed.priority > 0 {
+		return parsed.priority
+	}
+	return fallback
+}
+
+// drops reports whether the parsed message is not to be forwarded.
+func (pc *ParserConfig) drops(parsed *parsedMessage) bool {
+	for _, k := range pc.Drop {
+		if strings.EqualFold(k, parsed.kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateParsers checks the parser configurations.
+func validateParsers(configs map[string]ParserConfig) error {
+	for name, pc := range configs {
+		known := false
+		for _, p := range botParsers {
+			known = known || p.name == name
+		}
+		if !known {
+			return fmt.Errorf("unknown parser %q", name)
+		}
+		for kind, p := range pc.Priorities {
+			if p < 0 || p > 10 {
+				return fmt.Errorf("parser %s: priority of %q must be between 0 and 10", name, kind)
+			}
+		}
+	}
+	return nil
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"html"
 	"log"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gotify/plugin-api"
 	"github.com/nlopes/slack"
@@ -27,138 +29,1182 @@ func GetGotifyPluginInfo() plugin.Info {
 
 // Plugin is the gotify plugin instance.
 type Plugin struct {
-	enabled    bool
 	msgHandler plugin.MessageHandler
 	config     *Config
-	api        *slack.Client
-	rtm        *slack.RTM
-	uid        string
-	team       string
+	// baseConfig is the config last passed by gotify.
+	baseConfig *Config
+	// api, uid, team and teamID are set on connecting and guarded by mu.
+	api      *slack.Client
+	uid      string
+	team     string
+	teamID   string
+	basePath string
+
+	// connMu guards the connection lifecycle, see connection.go. done is
+	// closed to end the current run of the connection, stopped once it ended.
+	connMu  sync.Mutex
+	state   connState
+	done    chan struct{}
+	stopped chan struct{}
+
+	mu    sync.Mutex
+	muted map[string]time.Time
+	// actionKey signs action links, see actions.go.
+	actionKey []byte
+	// queued holds messages waiting for their channel window to open.
+	queued []queuedMessage
+	stats  stats
+	recent []logEntry
+	// activity tracks forwarded messages per conversation label.
+	activity map[string]*activity
+	flood    floodGate
+	// caps holds the hourly caps of conversations by channel ID.
+	caps map[string]*floodGate
+	// decay tracks recent notifications by channel ID for priority decay.
+	decay map[string]*decayState
+	// threads holds the summarized threads by channel ID and thread timestamp.
+	threads map[string]*summarizedThread
+	// digest collects the daily summary.
+	digest digest
+	// unread holds the unread counts last notified.
+	unread unreadCounts
+	// unreadSyncing is set while the unread messages are counted.
+	unreadSyncing bool
+	// cursors holds the timestamp of the last message polled by channel ID.
+	cursors map[string]string
+	// offsets holds the timestamp of the last message handled by channel ID,
+	// offsetsDirty whether it changed since it was persisted.
+	offsets      map[string]string
+	offsetsDirty bool
+	// latency keeps the delays between Slack and gotify.
+	latency latencies
+	// budget counts the Web API calls.
+	budget apiBudget
+	// mqtt is the connection to the MQTT broker.
+	mqtt mqttClient
+	// cachesLoaded tells whether the persisted lookup caches have been loaded.
+	cachesLoaded bool
+	// backfillPending tells whether the plugin has been enabled and the
+	// Startup mode not yet applied.
+	backfillPending bool
+	// escalations holds the notifications to repeat if still unread.
+	escalations []escalation
+	// apps caches the tokens of the gotify applications by conversation label.
+	apps map[string]string
+	// pollNext is the index of the next conversation to poll beyond the
+	// PollMaxConversations limit.
+	pollNext int
+	// batches holds messages waiting to be merged with follow-ups of their sender.
+	batches coalescer
+	// outbox holds messages to retry after gotify failed to accept them.
+	outbox outbox
+	// users, channels and botNames cache lookups by ID.
+	users    map[string]cachedUser
+	channels map[string]cachedChannel
+	botNames map[string]string
+	// overflowed counts the messages dropped from the full event queue
+	// since the last summary.
+	overflowed int
+	// queueStats describes the load of the event queue.
+	queueStats queueStats
+	// bursts tracks the activity bursts of channels in burst mode by ID.
+	bursts map[string]*burstState
+	// followed holds when the user last wrote in the threads they follow,
+	// by channel ID and thread timestamp.
+	followed map[string]time.Time
+	// teamNames caches the names of workspaces other than the token's by ID.
+	teamNames map[string]string
+	// prefs caches the user's Slack notification preferences.
+	prefs *notificationPrefs
+	// quiet overrides the configured quiet hours, see quietHours.
+	quiet *quietOverride
+	// groups holds the IDs of the user groups the user belongs to.
+	groups map[string]bool
+	// emoji caches the workspace's custom emoji by name.
+	emoji        map[string]string
+	emojiFetched time.Time
+	// canvasNotified holds when edits of a canvas were last notified by ID.
+	canvasNotified map[string]time.Time
+	// tokenExpiry is when the rotated token expires, zero without token rotation.
+	tokenExpiry time.Time
+	// storage persists the plugin state, see storedState.
+	stateMu sync.Mutex
+	storage plugin.StorageHandler
+	// lastHealthCheck is when the connection was last checked and
+	// healthWarned whether the last check failed and was warned about.
+	lastHealthCheck time.Time
+	healthWarned    bool
+	// logger writes to logFile if file logging is configured.
+	logMu   sync.Mutex
+	logFile *rotatingFile
+	logger  *log.Logger
+	// debugEvents holds the last DebugEvents events received.
+	debugEvents []debugEvent
+	// panicNotified is when a recovered panic was last notified.
+	panicNotified time.Time
+	// fault is the error that stopped the connection, nil while healthy.
+	fault error
+}
+
+// stats counts the messages handled since the plugin was enabled.
+type stats struct {
+	since     time.Time
+	forwarded int
+	filtered  int
+	errors    int
 }
 
 // Config is a user plugin configuration.
 type Config struct {
+	// Version is the schema version of the config, see migrate.
+	Version    int
 	SlackToken string
+	// RefreshToken enables token rotation: SlackToken, if set, is replaced
+	// by access tokens obtained with the refresh token and the app's
+	// ClientID and ClientSecret. Rotated tokens are kept in the plugin storage.
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+	// ChannelApps sends the messages of each conversation to its own gotify
+	// application, created on its first message through the REST API at
+	// GotifyURL with the client token GotifyClientToken.
+	ChannelApps       bool
+	GotifyURL         string
+	GotifyClientToken string
+	// AppTokens maps conversations ("#general", "@alice") to the tokens of
+	// existing gotify applications their messages are sent to via GotifyURL.
+	AppTokens map[string]string
+	// MirrorURL receives every forwarded message as JSON, signed with
+	// MirrorSecret if set, e.g. to trigger home automation.
+	MirrorURL    string
+	MirrorSecret string
+	// MQTTBroker ("tcp://host:1883" or "ssl://host:8883") receives every
+	// forwarded message as JSON on the topic rendered from the MQTTTopic
+	// template, "slack/{{.Conversation}}" by default.
+	MQTTBroker   string
+	MQTTUsername string
+	MQTTPassword string
+	MQTTTopic    string
+	// APIURL overrides the Slack Web API endpoint, e.g. for GovSlack or an internal gateway.
+	APIURL string
+	// CACertFile is a PEM bundle of additional trusted CAs, e.g. of a TLS-intercepting proxy.
+	CACertFile         string
+	InsecureSkipVerify bool
+	SigningSecret      string
+	QuietHours         Window
+	Profiles           []Profile
+	Locale             string
+	TitleParts         []string
+	TitleSeparator     string
+	TitleRules         []TitleRule
+	// MaxTitleLength caps the length of titles, as mobile clients truncate
+	// long titles unpredictably. The channel and sender are kept if possible.
+	MaxTitleLength int
+	// OmitSlack and OmitTeam drop the "Slack" and team parts from titles,
+	// e.g. for a single workspace, to save notification width.
+	OmitSlack bool
+	OmitTeam  bool
+	// Breakthrough lists the rules of urgent messages, see BreakthroughRule.
+	Breakthrough []BreakthroughRule
+	// Parsers configures the parsers turning messages of known bots into
+	// compact notifications by parser name: "alerts" for PagerDuty,
+	// Opsgenie and similar alerting bots, "github" for the GitHub app,
+	// "jira" for the Jira Cloud app, "ci" for Jenkins, GitLab, GitHub
+	// Actions and similar CI bots and "workflow" for Workflow Builder form
+	// submissions; list the names of workflows in its Bots.
+	// Parsers are enabled by default.
+	Parsers map[string]ParserConfig
+	// Redact lists regular expressions whose matches are replaced with
+	// "[redacted]" in forwarded titles and bodies.
+	Redact []string
+	// ScrubPII masks email addresses, phone numbers, credit card numbers and
+	// IBANs in forwarded bodies.
+	ScrubPII bool
+	// DropPatterns drops messages whose formatted text, including
+	// attachments, matches one of the regular expressions. DropSubtypes drops
+	// messages of the given subtypes and DropGIFs messages of only GIFs.
+	DropPatterns   []string
+	DropSubtypes   []string
+	DropGIFs       bool
+	FloodLimit     int
+	FloodWindow    time.Duration
+	Channels       []ChannelConfig
+	ChannelAliases map[string]string
+	// DefaultPriority is the gotify priority of messages no rule assigns a priority to.
+	DefaultPriority int
+	// MentionPriority is the priority of messages mentioning the user,
+	// personally or through one of their user groups. Zero means DefaultPriority.
+	MentionPriority int
+	// WarningPriority is the priority of the plugin's warnings, e.g. about a
+	// failed health check, and ErrorPriority that of errors, e.g. a lost
+	// connection.
+	WarningPriority int
+	ErrorPriority   int
+	// CoalesceWindow merges messages a sender sends within this duration of each other.
+	CoalesceWindow time.Duration
+	// ThreadSummary sends thread replies as roll-ups at this interval
+	// instead of one by one, except the first and those mentioning the user.
+	ThreadSummary time.Duration
+	// FollowThreads forwards all replies in threads the user started or
+	// replied to, even in channels Slack only notifies mentions of.
+	FollowThreads bool
+	// DigestTime is the time of day ("HH:MM") to send a summary of the
+	// day's forwarding at, empty to send none.
+	DigestTime string
+	// Transport is "rtm" (default) to receive events over a websocket or
+	// "poll" to poll the conversations' history where websockets are
+	// impossible. Polling only delivers messages.
+	Transport string
+	// ShareConnection lets Gotify users who configured the same Slack token
+	// share one connection instead of each connecting on their own. Only the
+	// event stream is shared: each user still looks up users, conversations
+	// and files on their own, counting against the same API rate limits.
+	ShareConnection bool
+	// PollInterval is how often conversations matching PollPriority are
+	// polled, the others only every PollSlowdown-th time. At most
+	// PollMaxConversations are polled at a time, zero for all of them.
+	PollInterval         time.Duration
+	PollSlowdown         int
+	PollMaxConversations int
+	PollPriority         []string
+	// QueueSize bounds the events waiting to be handled, 1000 by default.
+	// Overflow is "drop" (default) to drop the oldest waiting message when
+	// the queue is full, summarizing the dropped messages every minute, or
+	// "block" to stop receiving events until there is room.
+	QueueSize int
+	Overflow  string
+	// UnreadInterval is how often the unread messages and mentions are
+	// counted, no more often than every five minutes. Changed counts are notified
+	// with UnreadPriority.
+	UnreadInterval time.Duration
+	UnreadPriority int
+	// Startup is what happens to the messages posted while the plugin was
+	// disabled when it is enabled: "resume" (default) forwards those posted
+	// since the last forwarded message of each conversation, "live" only
+	// forwards new messages, "unread" forwards the unread direct messages and
+	// mentions and "recent" those of the last BackfillWindow.
+	Startup        string
+	BackfillWindow time.Duration
+	// Backfill is replaced by Startup "unread" and only kept to migrate
+	// older configs.
+	Backfill bool
+	// EscalateAfter repeats the notification of a direct message or mention
+	// with EscalatePriority if it is still unread in Slack after this
+	// duration. Zero disables escalation.
+	EscalateAfter    time.Duration
+	EscalatePriority int
+	// HealthCheckInterval is how often the token is checked with auth.test,
+	// independent of message traffic. Failures are notified right away.
+	HealthCheckInterval time.Duration
+	// ForwardOwn forwards the user's own messages, e.g. as a record of what
+	// was written on other devices.
+	ForwardOwn bool
+	// ForwardBots forwards messages of bots except those listed in IgnoredBots by name or ID.
+	ForwardBots bool
+	IgnoredBots []string
+	// Slackbot is "all" to forward Slackbot's messages, "direct" to forward
+	// only those in the direct conversation with it, e.g. reminders, but not
+	// its automatic responses in channels, or "none".
+	Slackbot string
+	// NameSource is the profile field naming users in titles and mentions:
+	// "realname" (default), "displayname" or "username".
+	NameSource string
+	// MentionFormat renders user mentions: "slack" (default) as "<@name>",
+	// "at" as "@name", "bold" as "**@name**", "name" as the bare name or
+	// "none" to strip them.
+	MentionFormat string
+	// NotifyInvitations notifies about invitations to channels.
+	NotifyInvitations bool
+	// NotifyReminders forwards due reminders, e.g. of messages saved for
+	// later, with the saved message's text and permalink.
+	NotifyReminders bool
+	// NotifyTopicChanges forwards changes of a channel's topic or purpose.
+	NotifyTopicChanges bool
+	// NotifyEmoji notifies with EmojiPriority when a custom emoji is added.
+	NotifyEmoji   bool
+	EmojiPriority int
+	// NotifyTeamJoin notifies with TeamJoinPriority when someone joins the workspace.
+	NotifyTeamJoin   bool
+	TeamJoinPriority int
+	// NotifyCelebrations forwards birthdays and work anniversaries posted by
+	// Slackbot or bots, including those listed in CelebrationBots by name or
+	// ID, even if bots are not forwarded otherwise.
+	NotifyCelebrations bool
+	CelebrationBots    []string
+	// NotifyMembership notifies with MembershipPriority when the user is added to or removed from a channel.
+	NotifyMembership   bool
+	MembershipPriority int
+	// NotifyCanvases notifies about edits of canvases and lists the user
+	// created or lists in FollowedCanvases by ID or title, and about
+	// comments mentioning the user.
+	NotifyCanvases   bool
+	FollowedCanvases []string
+	// UseSlackPreferences applies the notification preferences set in Slack.
+	UseSlackPreferences bool
+	// RespectSlackMutes skips channels muted in Slack except those in ForwardMutedChannels.
+	RespectSlackMutes    bool
+	ForwardMutedChannels []string
+	// LogFile additionally writes the plugin's log to a file, which is rotated
+	// at LogMaxSize megabytes keeping LogRetention old files.
+	LogFile      string
+	LogMaxSize   int
+	LogRetention int
+	// Actions makes notifications open the conversation in the Slack app and
+	// adds a link muting the channel for an hour, given PublicURL, the URL
+	// gotify is reachable at, e.g. "https://gotify.example.com".
+	Actions   bool
+	PublicURL string
+	// AdminSecret protects the config and events webhook routes, which are
+	// disabled without it. Requests pass it in the X-Admin-Secret header.
+	AdminSecret string
+	// DryRun evaluates all filters and rules and lists what would have been
+	// forwarded, without sending anything.
+	DryRun bool
+	// DebugEvents keeps the last DebugEvents Slack events, listed on the
+	// display page and served as JSON by the events webhook route given
+	// AdminSecret.
+	DebugEvents int
+	// Format is "slack" to keep Slack's markup or "plain" to strip it.
+	Format string
+	// CollapseLines joins the lines of a message with " ⏎ " and MaxLines keeps
+	// only its first lines, so lock screens show the gist of long messages.
+	CollapseLines bool
+	MaxLines      int
+	// ClipThumbnails shows the thumbnail of video clips. Slack serves them
+	// only to authorized requests, so few clients can display them.
+	ClipThumbnails bool
 }
 
 // Valid checks whether the API token in the config is valid.
 func (conf *Config) Valid() bool {
-	api := slack.New(conf.SlackToken)
-	_, err := api.AuthTest()
+	api, err := conf.client()
+	if err != nil {
+		return false
+	}
+	_, err = api.AuthTest()
 	return err == nil
 }
 
+// client creates a Slack API client for the config.
+func (conf *Config) client() (*slack.Client, error) {
+	return conf.countedClient(nil)
+}
+
+// countedClient creates a Slack API client for the config whose calls are
+// counted against the API budget of c, if not nil.
+func (conf *Config) countedClient(c *Plugin) (*slack.Client, error) {
+	hc, err := conf.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	options := []slack.Option{slack.OptionHTTPClient(hc)}
+	if c != nil {
+		options[0] = slack.OptionHTTPClient(countedClient{hc, c})
+	}
+	if conf.APIURL != "" {
+		options = append(options, slack.OptionAPIURL(conf.apiURL()))
+	}
+	return slack.New(conf.SlackToken, options...), nil
+}
+
 // DefaultConfig implements plugin.Configurer.
 func (c *Plugin) DefaultConfig() interface{} {
-	return &Config{}
+	return &Config{
+		Version:             configVersion,
+		Locale:              "en",
+		TitleParts:          defaultTitleParts,
+		TitleSeparator:      " | ",
+		Format:              "slack",
+		DefaultPriority:     5,
+		WarningPriority:     6,
+		ErrorPriority:       8,
+		RespectSlackMutes:   true,
+		ForwardBots:         true,
+		Slackbot:            "all",
+		NotifyInvitations:   true,
+		NotifyReminders:     true,
+		FollowThreads:       true,
+		NotifyTopicChanges:  true,
+		EmojiPriority:       1,
+		TeamJoinPriority:    2,
+		MembershipPriority:  3,
+		UnreadPriority:      1,
+		EscalatePriority:    8,
+		PollPriority:        []string{"@direct"},
+		FloodLimit:          60,
+		FloodWindow:         10 * time.Minute,
+		HealthCheckInterval: defaultHealthCheckInterval,
+		LogMaxSize:          10,
+		LogRetention:        3,
+	}
 }
 
 // ValidateAndSetConfig implements plugin.Configurer.
+// A config imported through the config webhook route takes precedence as
+// long as gotify passes the config it replaced.
 func (c *Plugin) ValidateAndSetConfig(conf interface{}) error {
 	config := conf.(*Config)
-	if config.SlackToken == "" {
-		return c.stopRTM()
+	imported, err := c.importedConfig(config)
+	if err != nil {
+		c.logln(err)
 	}
-	if !config.Valid() {
-		return errors.New("the token is invalid")
+	if imported != nil {
+		if err := c.applyConfig(imported); err == nil {
+			return nil
+		}
+		c.logln("discarding the imported config:", err)
 	}
-	c.config = config
-	if !c.enabled {
+	return c.applyConfig(config)
+}
+
+// applyConfig validates config and applies it.
+func (c *Plugin) applyConfig(config *Config) error {
+	if err := migrate(config); err != nil {
+		return err
+	}
+	if config.SlackToken == "" && config.RefreshToken == "" {
+		c.mu.Lock()
+		c.config = config
+		c.mu.Unlock()
+		c.restart()
 		return nil
 	}
-	err := c.stopRTM()
+	if config.RefreshToken != "" && (config.ClientID == "" || config.ClientSecret == "") {
+		return errors.New("ClientID and ClientSecret are required for token rotation")
+	}
+	if config.DigestTime != "" {
+		if _, err := parseClock(config.DigestTime); err != nil {
+			return err
+		}
+	}
+	if err := config.QuietHours.Validate(); err != nil {
+		return err
+	}
+	if _, ok := translations[config.Locale]; !ok && config.Locale != "" && config.Locale != "en" {
+		return fmt.Errorf("unsupported locale %q", config.Locale)
+	}
+	for _, part := range config.TitleParts {
+		if !validTitlePart(part) {
+			return fmt.Errorf("unknown title part %q", part)
+		}
+	}
+	if err := config.validateMQTT(); err != nil {
+		return err
+	}
+	if config.MirrorURL != "" {
+		if u, err := url.Parse(config.MirrorURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid MirrorURL %q", config.MirrorURL)
+		}
+	}
+	if config.PublicURL != "" {
+		if u, err := url.Parse(config.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid PublicURL %q", config.PublicURL)
+		}
+	}
+	if err := validateRedactions(config.Redact); err != nil {
+		return err
+	}
+	if err := validateDropPatterns(config.DropPatterns); err != nil {
+		return err
+	}
+	if err := validateChannelPatterns(config.ForwardMutedChannels); err != nil {
+		return err
+	}
+	if err := validateChannelPatterns(config.PollPriority); err != nil {
+		return err
+	}
+	if err := validateParsers(config.Parsers); err != nil {
+		return err
+	}
+	for _, r := range config.Breakthrough {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, r := range config.TitleRules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("title rule %q: %v", r.Prefix, err)
+		}
+	}
+	if config.ChannelApps && (config.GotifyURL == "" || config.GotifyClientToken == "") {
+		return errors.New("GotifyURL and GotifyClientToken are required for ChannelApps")
+	}
+	if len(config.AppTokens) != 0 && config.GotifyURL == "" {
+		return errors.New("GotifyURL is required for AppTokens")
+	}
+	for conv, token := range config.AppTokens {
+		if !strings.HasPrefix(conv, "#") && !strings.HasPrefix(conv, "@") || token == "" {
+			return fmt.Errorf("invalid app token mapping %q, expected \"#channel\" or \"@user\" and a token", conv)
+		}
+	}
+	if config.GotifyURL != "" {
+		if u, err := url.Parse(config.GotifyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid GotifyURL %q", config.GotifyURL)
+		}
+	}
+	if config.APIURL != "" {
+		if u, err := url.Parse(config.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid APIURL %q", config.APIURL)
+		}
+	}
+	if _, err := config.tlsConfig(); err != nil {
+		return err
+	}
+	switch config.Format {
+	case "", "slack", "plain":
+	default:
+		return fmt.Errorf("invalid format %q, expected slack or plain", config.Format)
+	}
+	switch config.MentionFormat {
+	case "", "slack", "at", "bold", "name", "none":
+	default:
+		return fmt.Errorf("invalid MentionFormat %q, expected slack, at, bold, name or none", config.MentionFormat)
+	}
+	switch config.NameSource {
+	case "", "realname", "displayname", "username":
+	default:
+		return fmt.Errorf("invalid NameSource %q, expected realname, displayname or username", config.NameSource)
+	}
+	switch config.Slackbot {
+	case "", "all", "direct", "none":
+	default:
+		return fmt.Errorf("invalid Slackbot setting %q, expected all, direct or none", config.Slackbot)
+	}
+	if config.LogMaxSize < 0 || config.LogRetention < 0 {
+		return errors.New("LogMaxSize and LogRetention must not be negative")
+	}
+	if config.MaxTitleLength < 0 {
+		return errors.New("MaxTitleLength must not be negative")
+	}
+	if config.MaxLines < 0 {
+		return errors.New("MaxLines must not be negative")
+	}
+	switch config.Startup {
+	case "", "resume", "live", "unread":
+	case "recent":
+		if config.BackfillWindow <= 0 {
+			return errors.New("BackfillWindow is required for the recent Startup mode")
+		}
+	default:
+		return fmt.Errorf("invalid Startup %q, expected resume, live, unread or recent", config.Startup)
+	}
+	if config.UnreadInterval > 0 && config.UnreadInterval < minUnreadInterval {
+		return fmt.Errorf("UnreadInterval must be at least %s", minUnreadInterval)
+	}
+	if config.QueueSize < 0 {
+		return errors.New("QueueSize must not be negative")
+	}
+	switch config.Overflow {
+	case "", "drop", "block":
+	default:
+		return fmt.Errorf("invalid Overflow %q, expected drop or block", config.Overflow)
+	}
+	switch config.Transport {
+	case "", "rtm", "poll":
+	default:
+		return fmt.Errorf("invalid Transport %q, expected rtm or poll", config.Transport)
+	}
+	if config.PollInterval < 0 || config.PollSlowdown < 0 || config.PollMaxConversations < 0 {
+		return errors.New("PollInterval, PollSlowdown and PollMaxConversations must not be negative")
+	}
+	if config.DefaultPriority < 0 || config.DefaultPriority > 10 {
+		return errors.New("DefaultPriority must be between 0 and 10")
+	}
+	if config.MentionPriority < 0 || config.MentionPriority > 10 {
+		return errors.New("MentionPriority must be between 0 and 10")
+	}
+	if config.WarningPriority < 0 || config.WarningPriority > 10 {
+		return errors.New("WarningPriority must be between 0 and 10")
+	}
+	if config.ErrorPriority < 0 || config.ErrorPriority > 10 {
+		return errors.New("ErrorPriority must be between 0 and 10")
+	}
+	if config.UnreadPriority < 0 || config.UnreadPriority > 10 {
+		return errors.New("UnreadPriority must be between 0 and 10")
+	}
+	if config.EscalatePriority < 0 || config.EscalatePriority > 10 {
+		return errors.New("EscalatePriority must be between 0 and 10")
+	}
+	if config.EmojiPriority < 0 || config.EmojiPriority > 10 {
+		return errors.New("EmojiPriority must be between 0 and 10")
+	}
+	if config.TeamJoinPriority < 0 || config.TeamJoinPriority > 10 {
+		return errors.New("TeamJoinPriority must be between 0 and 10")
+	}
+	if config.MembershipPriority < 0 || config.MembershipPriority > 10 {
+		return errors.New("MembershipPriority must be between 0 and 10")
+	}
+	if config.FloodLimit > 0 && config.FloodWindow <= 0 {
+		return errors.New("FloodWindow must be positive when FloodLimit is set")
+	}
+	for _, cc := range config.Channels {
+		if err := cc.Validate(); err != nil {
+			return fmt.Errorf("channels %v: %v", cc.Match, err)
+		}
+		if cc.DigestOnly && config.DigestTime == "" {
+			return fmt.Errorf("channels %v: DigestOnly requires a DigestTime", cc.Match)
+		}
+	}
+	for _, p := range config.Profiles {
+		if err := p.Window.Validate(); err != nil {
+			return fmt.Errorf("profile %q: %v", p.Name, err)
+		}
+		if p.Priority < 0 || p.Priority > 10 {
+			return fmt.Errorf("profile %q: Priority must be between 0 and 10", p.Name)
+		}
+	}
+	if err := c.setLogFile(config); err != nil {
+		return err
+	}
+	config, expiry, err := c.rotate(config, time.Now())
 	if err != nil {
 		return err
 	}
-	return c.startRTM()
+	if !config.Valid() {
+		return errors.New("the token is invalid")
+	}
+	c.mu.Lock()
+	c.config = config
+	c.tokenExpiry = expiry
+	c.mu.Unlock()
+	c.restart()
+	return nil
 }
 
 var mentionRe = regexp.MustCompile(`<@[^>]+>`)
 
-func (c *Plugin) startRTM() error {
-	c.api = slack.New(c.config.SlackToken)
-	atr, err := c.api.AuthTest()
-	if err != nil {
-		log.Println(err)
-		return err
+// eventLoop handles the events of the connection and periodic tasks until
+// done is closed or the connection has to be given up.
+func (c *Plugin) eventLoop(events <-chan slack.RTMEvent, done chan struct{}) error {
+	healthCheck := time.NewTicker(c.healthCheckInterval())
+	defer healthCheck.Stop()
+	minute := time.NewTicker(time.Minute)
+	defer minute.Stop()
+	unread, stopUnread := c.unreadTicker()
+	defer stopUnread()
+	// A rotated token is refreshed by reconnecting shortly before it expires.
+	var refresh <-chan time.Time
+	c.mu.Lock()
+	expiry := c.tokenExpiry
+	c.mu.Unlock()
+	if !expiry.IsZero() {
+		timer := time.NewTimer(time.Until(expiry.Add(-refreshMargin)))
+		defer timer.Stop()
+		refresh = timer.C
 	}
-	c.uid = atr.UserID
-	c.team = atr.Team
-	c.rtm = c.api.NewRTM()
-	go c.rtm.ManageConnection()
-
-	for msg := range c.rtm.IncomingEvents {
-		switch ev := msg.Data.(type) {
-		case *slack.MessageEvent:
-			channel, err := c.api.GetConversationInfo(ev.Msg.Channel, true)
-			if err != nil {
-				log.Println(err)
-				continue
-			}
-			uid := ev.Msg.User
-			text := ev.Msg.Text
-			edited := false
-			if ev.SubMessage != nil && ev.SubMessage.Edited != nil {
-				uid = ev.SubMessage.User
-				text = ev.PreviousMessage.Text + "\n-----\n" + ev.SubMessage.Text
-				edited = true
-			}
-			user, err := c.api.GetUserInfo(uid)
-			if err != nil {
-				log.Println(err)
-				continue
+	defer func() {
+		if err := c.saveOffsets(); err != nil {
+			c.logln(err)
+		}
+	}()
+	if c.startup(time.Now()) {
+		c.catchUp()
+	}
+	for {
+		select {
+		case <-done:
+			return nil
+		case now := <-minute.C:
+			c.flushQueued(now)
+			c.flushThreads(now)
+			c.sendDigest(now)
+			c.escalate(now)
+			c.sendOverflowSummary()
+			if err := c.saveOffsets(); err != nil {
+				c.logln(err)
 			}
-			if user.ID == c.uid {
-				continue
+		case <-refresh:
+			return errReconnect
+		case <-unread:
+			// Counting takes calls for every conversation and must not
+			// hold up the events.
+			go c.safely(c.syncUnread)
+		case <-healthCheck.C:
+			if err := c.checkHealth(); err != nil {
+				return err
 			}
-			title := "Slack | " + c.team + " | "
-			if channel.Name != "" {
-				title += channel.Name + " | "
+		case msg := <-events:
+			c.captureEvent(msg)
+			switch ev := msg.Data.(type) {
+			case *slack.MessageEvent:
+				var blocks json.RawMessage
+				if len(ev.Msg.Blocks.BlockSet) != 0 {
+					blocks, _ = json.Marshal(ev.Msg.Blocks)
+				}
+				c.handleMessage(ev, blocks)
+
+			case *slack.UnmarshallingErrorEvent:
+				if ev, blocks, ok := recoverMessage(ev); ok {
+					c.handleMessage(ev, blocks)
+				}
+
+			case *slack.MemberJoinedChannelEvent:
+				c.handleMembership(ev.User, ev.Channel, ev.Inviter, true)
+
+			case *slack.MemberLeftChannelEvent:
+				c.handleMembership(ev.User, ev.Channel, "", false)
+
+			case *slack.FileChangeEvent:
+				c.handleCanvasChange(ev.File.ID)
+
+			case *slack.FileCommentAddedEvent:
+				c.handleCanvasComment(ev.File.ID, ev.Comment)
+
+			case *slack.SubteamSelfAddedEvent:
+				c.setGroup(ev.SubteamID, true)
+
+			case *slack.SubteamSelfRemovedEvent:
+				c.setGroup(ev.SubteamID, false)
+
+			case *slack.UserChangeEvent:
+				c.updateUser(ev.User)
+
+			case *slack.ChannelRenameEvent:
+				c.renameChannel(ev.Channel.ID, ev.Channel.Name)
+
+			case *slack.GroupRenameEvent:
+				c.renameChannel(ev.Group.ID, ev.Group.Name)
+
+			case *slack.ChannelLeftEvent:
+				c.forgetChannel(ev.Channel)
+
+			case *slack.GroupLeftEvent:
+				c.forgetChannel(ev.Channel)
+
+			case *slack.TeamJoinEvent:
+				c.handleTeamJoin(&ev.User)
+
+			case *slack.EmojiChangedEvent:
+				c.handleEmojiChanged(ev)
+
+			case *sharedChannelInviteEvent:
+				c.handleSharedInvite(ev)
+
+			case *slack.PrefChangeEvent:
+				if ev.Name == "all_notifications_prefs" || ev.Name == "muted_channels" {
+					if err := c.loadPrefs(); err != nil {
+						c.logln(err)
+					}
+				}
+
+			case *sharedGoneEvent:
+				return errReconnect
+
+			case *tokensRevokedEvent:
+				return authFailure{errors.New("the token has been revoked")}
+
+			case *slack.InvalidAuthEvent:
+				return authFailure{errors.New("invalid credentials")}
 			}
-			title += user.RealName
-			if edited {
-				title += " [Edit]"
+		}
+	}
+}
+
+// handleMessage forwards a message event. blocks holds the raw Block Kit
+// blocks of the message, if any.
+func (c *Plugin) handleMessage(ev *slack.MessageEvent, blocks json.RawMessage) {
+	if !c.advanceOffset(ev.Msg.Channel, ev.Msg.Timestamp) {
+		return
+	}
+	if (ev.Msg.SubType == "channel_join" || ev.Msg.SubType == "group_join") && ev.Msg.User == c.userID() {
+		if ev.Msg.Inviter != "" {
+			c.handleInvitation(&ev.Msg)
+		}
+		return
+	}
+	channel, err := c.conversationInfo(ev.Msg.Channel)
+	if err != nil {
+		c.logln(err)
+		c.record(ev.Msg.Channel, ev.Msg.Channel, "error: "+err.Error())
+		return
+	}
+	if c.handleReminder(&ev.Msg, channel) {
+		return
+	}
+	c.forward(ev, blocks, channel)
+}
+
+// forward filters, formats and delivers a message posted in channel.
+func (c *Plugin) forward(ev *slack.MessageEvent, blocks json.RawMessage, channel *slack.Channel) {
+	author := &ev.Msg
+	text := ev.Msg.Text
+	edited := false
+	if ev.SubMessage != nil && ev.SubMessage.Edited != nil {
+		author = ev.SubMessage
+		text = ev.PreviousMessage.Text + "\n-----\n" + ev.SubMessage.Text
+		edited = true
+	}
+	from, err := c.resolveSender(author)
+	if err != nil {
+		c.logln(err)
+		c.record(ev.Msg.Channel, c.channelName(channel), "error: "+err.Error())
+		return
+	}
+	if channel.IsIM && !edited {
+		c.trackAnswer(channel.ID, conversationLabel("", from.name, true), from.id == c.userID())
+	}
+	if from.id == c.userID() {
+		if !edited {
+			c.followThread(&ev.Msg, time.Now())
+		}
+		if !c.forwardOwn() {
+			return
+		}
+	}
+	// Apps write to their App Home as the app's bot user; title such
+	// messages with the app's name and render their blocks, which often
+	// carry all of the content.
+	app := channel.IsIM && from.bot && author.BotID != ""
+	if app {
+		from.name = c.botName(author.BotID)
+	}
+	if rendered := renderBlocks(blocks); rendered != "" && (app || strings.TrimSpace(text) == "") && !edited {
+		text = rendered
+	}
+	attachments := ev.Msg.Attachments
+	var parsed *parsedMessage
+	var parser *ParserConfig
+	var parserName string
+	if !edited {
+		parserName, parsed, parser = c.parseBotMessage(from, author, blocks)
+	}
+	if parsed != nil {
+		// Parsed messages carry the content of their attachments already.
+		text = parsed.text
+		attachments = nil
+	}
+	team := ev.Msg.Team
+	if team == "" {
+		team, _ = c.homeTeam()
+	}
+	parts := titleParts{team: c.teamName(team), channel: c.channelName(channel), user: from.name}
+	conv := conversationLabel(parts.channel, parts.user, channel.IsIM)
+	base := c.title(parts)
+	if edited {
+		base += " " + tr(c.locale(), "[Edit]")
+	}
+	var prefixes []string
+	if prefix := c.titlePrefix(channel, text); prefix != "" {
+		prefixes = append(prefixes, prefix)
+	}
+	if parsed != nil && parsed.label != "" {
+		prefixes = append(prefixes, parsed.label)
+	}
+	title := c.prefixTitle(base, prefixes...)
+	if topic, ok := c.topicText(&ev.Msg, from.name, parts.channel); ok {
+		c.mu.Lock()
+		enabled := c.config.NotifyTopicChanges
+		c.mu.Unlock()
+		if !enabled {
+			c.record(conv, title, filteredBy("topic change"))
+			return
+		}
+		text = topic
+	}
+	// Celebrations are forwarded regardless of the bot and preference filters.
+	celebration := !edited && c.isCelebration(from, text)
+	if celebration {
+		prefixes = append([]string{tr(c.locale(), "Celebration")}, prefixes...)
+		title = c.prefixTitle(base, prefixes...)
+	}
+	urgent := c.breakthrough(channel, text)
+	policy := c.channelConfig(channel)
+	if policy == nil {
+		policy = &ChannelConfig{}
+	}
+	if reason := c.filterReason(ev.Msg.Channel, time.Now()); reason != "" && !(reason == ruleQuietHours && (urgent || policy.BypassQuietHours)) {
+		c.record(conv, title, filteredBy(string(reason)))
+		return
+	}
+	if reason := c.slackbotReason(from, channel.IsIM); reason != "" && !celebration && !urgent {
+		c.record(conv, title, filteredBy(reason))
+		return
+	}
+	if reason := c.botReason(from); reason != "" && !celebration && !urgent {
+		c.record(conv, title, filteredBy(reason))
+		return
+	}
+	// Replies in threads the user follows are forwarded like mentions.
+	followed := !edited && c.following(&ev.Msg)
+	if reason := c.preferenceReason(channel, text); reason != "" && !celebration && !urgent && !(followed && reason == ruleMentionsOnly) {
+		c.record(conv, title, filteredBy(string(reason)))
+		return
+	}
+	msgtext := mentionRe.ReplaceAllStringFunc(text, c.replaceMention)
+	plain := c.plainFormat()
+	if plain {
+		msgtext = plainText(msgtext)
+	}
+	if len(attachments) != 0 {
+		for _, att := range attachments {
+			if plain {
+				msgtext += "\n" + plainText(attachmentText(att))
+			} else {
+				msgtext += "\n> " + strings.Replace(attachmentText(att), "\n", "\n> ", -1)
 			}
-			msgtext := mentionRe.ReplaceAllStringFunc(text, func(s string) string {
-				userid := strings.Trim(s, "<@>")
-				user, err := c.api.GetUserInfo(userid)
+		}
+	}
+	msgtext = unescape(msgtext)
+	var thumb string
+	if len(ev.Msg.Files) != 0 {
+		var files string
+		files, thumb = c.fileText(ev.Msg.Files, plain)
+		if msgtext != "" {
+			msgtext += "\n"
+		}
+		msgtext += files
+	}
+	if reason := c.contentReason(&ev.Msg, msgtext); reason != "" {
+		c.record(conv, title, filteredBy(reason))
+		return
+	}
+	if parsed != nil && parser.drops(parsed) {
+		c.record(conv, title, filteredBy(parserName+" "+parsed.kind))
+		return
+	}
+	if policy.DigestOnly && !urgent {
+		c.record(conv, title, dispositionDigestOnly)
+		return
+	}
+	if !edited && !urgent && !followed && c.summarizeThread(&ev.Msg, channel, conv, title, text, time.Now()) {
+		c.record(conv, title, "summarized in thread")
+		return
+	}
+	if policy.BurstWindow > 0 && !edited && !urgent {
+		summary := c.title(titleParts{team: parts.team, channel: parts.channel})
+		if c.burst(channel, conv, summary, team, policy.BurstWindow) {
+			c.record(conv, title, "suppressed in burst")
+			return
+		}
+	}
+	c.mu.Lock()
+	collapse, maxLines := c.config.CollapseLines, c.config.MaxLines
+	c.mu.Unlock()
+	if collapse || maxLines > 0 {
+		msgtext = collapseLines(msgtext, maxLines, collapse)
+	}
+	priority := breakthroughPriority
+	if !urgent {
+		var reason string
+		priority = c.messagePriority(text)
+		if policy.Priority > 0 {
+			priority = policy.Priority
+		}
+		if parsed != nil {
+			priority = parser.priority(parsed, priority)
+		}
+		priority = c.applyDecay(channel, priority, time.Now())
+		// The profile is the final stage.
+		priority, reason = c.applyProfile(priority, channel.IsIM, time.Now())
+		if reason != "" {
+			c.record(conv, title, filteredBy(reason))
+			return
+		}
+	}
+	msg := plugin.Message{
+		Title:    title,
+		Message:  msgtext,
+		Priority: priority,
+	}
+	setExtra(&msg, "slack::message", "channel", channel.ID)
+	setExtra(&msg, "slack::message", "user", from.id)
+	setExtra(&msg, "slack::message", "team", team)
+	setExtra(&msg, "slack::message", "ts", author.Timestamp)
+	setExtra(&msg, "slack::message", "thread_ts", author.ThreadTimestamp)
+	if u := c.customEmojiURL(text); u != "" {
+		setExtra(&msg, "client::notification", "bigImageUrl", u)
+	} else if thumb != "" && c.clipThumbnails() {
+		setExtra(&msg, "client::notification", "bigImageUrl", thumb)
+	}
+	var permalink string
+	if ts := ev.Msg.ThreadTimestamp; ts != "" && ts != ev.Msg.Timestamp {
+		link, err := c.slackAPI().GetPermalink(&slack.PermalinkParameters{Channel: ev.Msg.Channel, Ts: ev.Msg.Timestamp})
+		if err != nil {
+			c.logln(err)
+		} else {
+			permalink = link
+			msg.Message += "\n" + tr(c.locale(), "Thread") + ": " + link
+			setExtra(&msg, "client::notification", "click", map[string]string{"url": link})
+		}
+	}
+	c.applyTemplates(channel, &msg, templateData{
+		Team:    parts.team,
+		Channel: parts.channel,
+		User:    parts.user,
+		Text:    msgtext,
+		permalink: func() string {
+			if api := c.slackAPI(); permalink == "" && api != nil {
+				link, err := api.GetPermalink(&slack.PermalinkParameters{Channel: ev.Msg.Channel, Ts: author.Timestamp})
 				if err != nil {
-					return "@Error"
-				}
-				return fmt.Sprintf("<@%s>", user.RealName)
-			})
-			msgtext = html.UnescapeString(msgtext)
-			if len(ev.Msg.Attachments) != 0 {
-				for _, att := range ev.Msg.Attachments {
-					msgtext += "\n> " + att.Fallback
+					c.logln(err)
 				}
+				permalink = link
 			}
-			if len(ev.Msg.Files) != 0 {
-				var titles []string
-				for _, file := range ev.Msg.Files {
-					titles = append(titles, file.Title)
-				}
-				msgtext += "\nFiles: " + strings.Join(titles, ", ")
+			return permalink
+		},
+	})
+	if parsed != nil && parsed.url != "" {
+		setExtra(&msg, "client::notification", "click", map[string]string{"url": parsed.url})
+	}
+	c.addActions(&msg, team, channel.ID)
+	// Direct messages and mentions are watched for escalation once sent.
+	if channel.IsIM {
+		setExtra(&msg, "slack::message", "notify", "direct")
+	} else if c.mentionsMe(text) {
+		setExtra(&msg, "slack::message", "notify", "mention")
+	}
+	if urgent {
+		c.emit(conv, msg)
+		return
+	}
+	c.mu.Lock()
+	window := c.config.CoalesceWindow
+	c.mu.Unlock()
+	if window > 0 && !edited {
+		c.coalesce(channel, conv, from.id, msg, window)
+		return
+	}
+	c.deliver(channel, conv, msg)
+}
+
+// deliver sends a composed message from the conversation labeled conv unless
+// its channel window, hourly cap or the flood limit hold it back.
+func (c *Plugin) deliver(channel *slack.Channel, conv string, msg plugin.Message) {
+	now := time.Now()
+	if held := c.applyChannelWindow(channel, conv, msg, now); held != "" {
+		c.record(conv, msg.Title, held)
+		return
+	}
+	if !c.allowCap(channel, now) {
+		c.record(conv, msg.Title, filteredBy("hourly cap"))
+		return
+	}
+	if !c.allowFlood(now) {
+		c.record(conv, msg.Title, filteredBy("flood limit"))
+		return
+	}
+	c.emit(conv, msg)
+}
+
+// setExtra sets key in the given extras namespace of msg.
+func setExtra(msg *plugin.Message, namespace, key string, value interface{}) {
+	if msg.Extras == nil {
+		msg.Extras = make(map[string]interface{})
+	}
+	ns, ok := msg.Extras[namespace].(map[string]interface{})
+	if !ok {
+		ns = make(map[string]interface{})
+		msg.Extras[namespace] = ns
+	}
+	ns[key] = value
+}
+
+// filterReason returns why messages from the given channel are currently
+// not forwarded, or an empty string if they are.
+func (c *Plugin) filterReason(channel string, now time.Time) filterRule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until, ok := c.muted[channel]; ok {
+		if until.IsZero() || now.Before(until) {
+			return ruleMuted
+		}
+		delete(c.muted, channel)
+	}
+	if c.quietHours().Contains(now) {
+		return ruleQuietHours
+	}
+	return ""
+}
+
+// title composes a notification title as configured.
+func (c *Plugin) title(p titleParts) string {
+	c.mu.Lock()
+	parts, sep := c.config.TitleParts, c.config.TitleSeparator
+	omitSlack, omitTeam := c.config.OmitSlack, c.config.OmitTeam
+	c.mu.Unlock()
+	if len(parts) == 0 {
+		parts = defaultTitleParts
+	}
+	if sep == "" {
+		sep = " | "
+	}
+	if omitSlack || omitTeam {
+		var kept []string
+		for _, part := range parts {
+			if !(omitSlack && part == "slack") && !(omitTeam && part == "team") {
+				kept = append(kept, part)
 			}
-			c.msgHandler.SendMessage(plugin.Message{
-				Title:    title,
-				Message:  msgtext,
-				Priority: 5,
-			})
+		}
+		parts = kept
+	}
+	return p.compose(parts, sep)
+}
 
-		case *slack.InvalidAuthEvent:
-			return errors.New("invalid credentials")
+// applyProfile applies the time-of-day profile active at now to a message
+// with the given priority and returns its final priority. If the message
+// must be dropped, it returns the rule responsible for it.
+func (c *Plugin) applyProfile(priority int, direct bool, now time.Time) (int, string) {
+	c.mu.Lock()
+	profile := activeProfile(c.config.Profiles, now)
+	c.mu.Unlock()
+	if profile == nil {
+		return priority, ""
+	}
+	if (profile.DirectOnly && !direct) || priority < profile.MinPriority {
+		return priority, "profile " + profile.Name
+	}
+	if profile.Priority > 0 {
+		priority = profile.Priority
+	}
+	return priority, ""
+}
+
+// allowFlood checks the global flood limit. The first suppressed message
+// schedules a summary; a pending summary is sent as soon as messages pass again.
+func (c *Plugin) allowFlood(now time.Time) bool {
+	c.mu.Lock()
+	limit, window := c.config.FloodLimit, c.config.FloodWindow
+	c.mu.Unlock()
+	if !c.flood.allow(now, limit, window) {
+		if c.flood.suppressedOnce() {
+			time.AfterFunc(window, func() { c.safely(c.sendFloodSummary) })
 		}
+		return false
 	}
-	return nil
+	c.sendFloodSummary()
+	return true
 }
 
-func (c *Plugin) stopRTM() error {
-	if c.rtm == nil {
-		c.api = nil
-		return nil
+// sendFloodSummary tells the user how many messages the flood limit suppressed.
+func (c *Plugin) sendFloodSummary() {
+	n := c.flood.takeSuppressed()
+	if n == 0 || c.dryRun() {
+		return
+	}
+	c.send(plugin.Message{
+		Title:    c.title(titleParts{team: c.teamName("")}),
+		Message:  fmt.Sprintf(tr(c.locale(), "+%d Slack messages suppressed, see Slack"), n),
+		Priority: c.defaultPriority(),
+	})
+}
+
+// fail marks the connection as broken and notifies the user about it,
+// unless it already was.
+func (c *Plugin) fail(err error) {
+	c.logln(err)
+	c.mu.Lock()
+	broken := c.fault != nil
+	c.fault = err
+	l, priority := c.config.Locale, c.config.ErrorPriority
+	c.mu.Unlock()
+	if broken {
+		return
 	}
-	return c.rtm.Disconnect()
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Connection lost")),
+		Message:  fmt.Sprintf(tr(l, "No more Slack messages will be forwarded: %s. Please check the Slack API token."), err),
+		Priority: priority,
+	})
 }
 
 // Enable enables the plugin.
@@ -166,39 +1212,155 @@ func (c *Plugin) Enable() error {
 	if c.config == nil {
 		return errors.New("please configure the slack api token first")
 	}
+	if err := c.refreshConfig(); err != nil {
+		return err
+	}
 	if !c.config.Valid() {
 		return errors.New("the slack api token is not valid anymore")
 	}
-	c.enabled = true
-	go c.startRTM()
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.state != stateDisabled && c.state != stateStopping {
+		return nil
+	}
+	c.mu.Lock()
+	c.stats = stats{since: time.Now()}
+	c.backfillPending = true
+	c.mu.Unlock()
+	c.start()
 	return nil
 }
 
 // Disable disables the plugin.
 func (c *Plugin) Disable() error {
-	err := c.stopRTM()
-	if err != nil {
-		return err
-	}
-	c.enabled = false
+	c.connMu.Lock()
+	c.stop()
+	c.connMu.Unlock()
+	c.mqtt.close()
 	return nil
 }
 
 // GetDisplay implements plugin.Displayer.
 func (c *Plugin) GetDisplay(location *url.URL) string {
-	return fmt.Sprintf(`
-## Status
+	l := c.locale()
+	state := c.connectionState()
+	c.mu.Lock()
+	connection := tr(l, "ok")
+	if state != stateConnected {
+		connection = tr(l, state.String())
+	}
+	if c.fault != nil {
+		connection = tr(l, "error") + ": " + c.fault.Error()
+	}
+	lastCheck := "-"
+	if !c.lastHealthCheck.IsZero() {
+		lastCheck = c.lastHealthCheck.Format("2006-01-02 15:04")
+	}
+	dryRun := c.config != nil && c.config.DryRun
+	c.mu.Unlock()
+	latency := "-"
+	if ps, ok := c.latency.percentiles(0.5, 0.95); ok {
+		latency = fmt.Sprintf("p50 %s, p95 %s", ps[0].Round(time.Millisecond), ps[1].Round(time.Millisecond))
+	}
+	display := fmt.Sprintf("\n## %s\n\n- %s: %s\n- %s: %s\n- %s: %s\n- %s: %s\n- %s: %s\n- %s: %s\n\n"+tr(l, "Tip: You can get your API token [here](%s).")+"\n",
+		tr(l, "Status"),
+		tr(l, "Plugin enabled"), trBool(l, state != stateDisabled && state != stateStopping),
+		tr(l, "Valid API token"), trBool(l, c.config != nil),
+		tr(l, "Connection"), connection,
+		tr(l, "Last health check"), lastCheck,
+		tr(l, "Latency"), latency,
+		tr(l, "Event queue"), c.queueStatus(l),
+		"https://api.slack.com/custom-integrations/legacy-tokens")
+	if dryRun {
+		display += "\n**" + tr(l, "Dry run: nothing is sent to gotify, the recent messages show what would have been forwarded.") + "**\n"
+	}
+	if u := c.routeURL(location, "test"); u != "" {
+		display += "\n[" + tr(l, "Send a test message") + "](" + u + ")\n"
+	}
+	display += "\n## " + tr(l, "Recent messages") + "\n\n" + c.recentTable(l)
+	display += "\n## " + tr(l, "Activity") + "\n\n" + c.activityTable(l)
+	display += "\n## " + tr(l, "API calls") + "\n\n" + c.budgetTable(l)
+	c.mu.Lock()
+	debug := c.config != nil && c.config.DebugEvents > 0
+	protected := c.config != nil && c.config.AdminSecret != ""
+	c.mu.Unlock()
+	if debug {
+		display += "\n## " + tr(l, "Debug events") + "\n\n"
+		if u := c.routeURL(location, "events"); u != "" && protected {
+			display += fmt.Sprintf(tr(l, "The events are available as JSON at `%s`, passing AdminSecret in the `X-Admin-Secret` header."), u) + "\n\n"
+		}
+		display += c.debugTable(l)
+	}
+	if u := c.commandURL(location); u != "" {
+		display += fmt.Sprintf("\n## %s\n\n"+
+			tr(l, "Create a slash command `/gotify` in your Slack app with the request URL `%s` and set `SigningSecret` to the app's signing secret.")+"\n",
+			tr(l, "Slash command"), u)
+	}
+	return display
+}
+
+// slackAPI returns the Slack API client of the current connection.
+func (c *Plugin) slackAPI() *slack.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.api
+}
+
+// userID returns the ID of the connected user.
+func (c *Plugin) userID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.uid
+}
 
-- Plugin enabled: %t
-- Valid API token: %t
+// homeTeam returns the ID and name of the workspace of the token.
+func (c *Plugin) homeTeam() (id, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.teamID, c.team
+}
 
-Tip: You can get your API token [here](https://api.slack.com/custom-integrations/legacy-tokens).
-	`, c.enabled, c.config != nil)
+// defaultPriority returns the configured default priority.
+func (c *Plugin) defaultPriority() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.DefaultPriority
 }
 
-// SetMessageHandler implements plugin.Messenger.
-func (c *Plugin) SetMessageHandler(h plugin.MessageHandler) {
-	c.msgHandler = h
+// messagePriority returns the priority of a message with the given raw text
+// before profiles apply.
+func (c *Plugin) messagePriority(text string) int {
+	mentioned := c.mentionsMe(text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if mentioned && c.config.MentionPriority > 0 {
+		return c.config.MentionPriority
+	}
+	return c.config.DefaultPriority
+}
+
+// clipThumbnails reports whether thumbnails of video clips are attached.
+func (c *Plugin) clipThumbnails() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.ClipThumbnails
+}
+
+// plainFormat reports whether Slack markup is to be stripped.
+func (c *Plugin) plainFormat() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.Format == "plain"
+}
+
+// locale returns the configured locale.
+func (c *Plugin) locale() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config == nil {
+		return ""
+	}
+	return c.config.Locale
 }
 
 // NewGotifyPluginInstance creates a plugin instance for a user context.
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gotify/plugin-api"
@@ -9,5 +13,84 @@ import (
 
 func TestAPICompatibility(t *testing.T) {
 	assert.Implements(t, (*plugin.Plugin)(nil), new(Plugin))
+	assert.Implements(t, (*plugin.Webhooker)(nil), new(Plugin))
+	assert.Implements(t, (*plugin.Storager)(nil), new(Plugin))
 	// Add other interfaces you intend to implement here
 }
+
+func TestPriorityRange(t *testing.T) {
+	c := &Plugin{}
+	for _, set := range []func(*Config){
+		func(conf *Config) { conf.EmojiPriority = 11 },
+		func(conf *Config) { conf.TeamJoinPriority = -1 },
+		func(conf *Config) { conf.MembershipPriority = 11 },
+	} {
+		config := c.DefaultConfig().(*Config)
+		config.SlackToken = "xoxp-test"
+		set(config)
+		assert.Error(t, c.applyConfig(config))
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"ok":true,"user_id":"U1"}`)
+	}))
+	defer srv.Close()
+
+	c := &Plugin{}
+	config := c.DefaultConfig().(*Config)
+	config.SlackToken = "xoxp-test"
+	assert.Equal(t, "https://slack.com/api/", config.apiURL())
+	config.APIURL = "slack.example.com/api"
+	assert.Error(t, c.applyConfig(config))
+	config.APIURL = srv.URL + "/api"
+	assert.NoError(t, c.applyConfig(config))
+	assert.Equal(t, srv.URL+"/api/", config.apiURL())
+	api, err := config.client()
+	assert.NoError(t, err)
+	_, err = api.AuthTest()
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/auth.test", path)
+}
+
+func TestFail(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config), team: "Acme"}
+	c.fail(errors.New("invalid_auth"))
+	c.fail(errors.New("account_inactive"))
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme | Connection lost", h.sent[0].Title)
+		assert.Contains(t, h.sent[0].Message, "invalid_auth")
+		assert.Equal(t, 8, h.sent[0].Priority)
+	}
+	assert.EqualError(t, c.fault, "account_inactive")
+}
+
+func TestGetDisplayConnection(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	for _, tc := range []struct {
+		state connState
+		fault error
+		want  string
+	}{
+		{stateDisabled, nil, "- Connection: disabled\n"},
+		{stateConnecting, nil, "- Connection: connecting\n"},
+		{stateConnected, nil, "- Connection: ok\n"},
+		{stateBackoff, nil, "- Connection: waiting to reconnect\n"},
+		{stateWaiting, errors.New("invalid_auth"), "- Connection: error: invalid_auth\n"},
+	} {
+		c.state, c.fault = tc.state, tc.fault
+		assert.Contains(t, c.GetDisplay(nil), tc.want)
+	}
+
+	c.config.Locale = "de"
+	c.state, c.fault = stateWaiting, nil
+	display := c.GetDisplay(nil)
+	assert.Contains(t, display, "- Verbindung: warte auf eine neue Konfiguration\n")
+	assert.Contains(t, display, "- Plugin aktiviert: ja\n")
+	c.state = stateDisabled
+	assert.Contains(t, c.GetDisplay(nil), "- Plugin aktiviert: nein\n")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	// defaultPollInterval is how often conversations are polled if
+	// PollInterval is not set.
+	defaultPollInterval = time.Minute
+	// defaultPollSlowdown is how many polls conversations not listed in
+	// PollPriority skip if PollSlowdown is not set.
+	defaultPollSlowdown = 5
+)
+
+// pollSettings returns the interval, slowdown, conversation limit and
+// priority patterns of polling.
+func (c *Plugin) pollSettings() (interval time.Duration, slowdown, max int, priority []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interval, slowdown = c.config.PollInterval, c.config.PollSlowdown
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if slowdown <= 0 {
+		slowdown = defaultPollSlowdown
+	}
+	return interval, slowdown, c.config.PollMaxConversations, c.config.PollPriority
+}
+
+// poll feeds new messages of the user's conversations into events as
+// message events until stop is closed. It is the transport for networks
+// where the RTM websocket cannot be used.
+func (c *Plugin) poll(events chan<- slack.RTMEvent, stop chan struct{}) {
+	interval, _, _, _ := c.pollSettings()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for round := 0; ; round++ {
+		if err := c.pollOnce(events, stop, round); err != nil {
+			c.logln(err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollOnce fetches the messages posted since the last poll of the
+// conversations due in the given round. The first poll of a conversation
+// only sets its cursor, so that old messages are not forwarded.
+func (c *Plugin) pollOnce(events chan<- slack.RTMEvent, stop chan struct{}, round int) error {
+	channels, err := c.memberConversations()
+	if err != nil {
+		return err
+	}
+	for _, ch := range c.pollDue(channels, round) {
+		c.mu.Lock()
+		oldest, ok := c.cursors[ch.ID]
+		c.mu.Unlock()
+		if !ok {
+			c.setCursor(ch.ID, timestamp(time.Now()))
+			continue
+		}
+		messages, err := c.history(ch.ID, oldest)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			m.Type = "message"
+			m.Channel = ch.ID
+			select {
+			case events <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: m.Msg, SubMessage: m.SubMessage}}:
+			case <-stop:
+				return nil
+			}
+			c.setCursor(ch.ID, m.Timestamp)
+		}
+	}
+	return nil
+}
+
+// pollDue selects the conversations to poll in the given round: those
+// matching PollPriority every round, the others every PollSlowdown-th round.
+// With PollMaxConversations, the others take turns in the remaining room.
+func (c *Plugin) pollDue(channels []slack.Channel, round int) []slack.Channel {
+	_, slowdown, max, priority := c.pollSettings()
+	var due, others []slack.Channel
+	for _, ch := range channels {
+		if matchChannel(priority, &ch) {
+			due = append(due, ch)
+		} else {
+			others = append(others, ch)
+		}
+	}
+	if max > 0 && len(due) > max {
+		due = due[:max]
+	}
+	if round%slowdown != 0 || len(others) == 0 {
+		return due
+	}
+	room := len(others)
+	if max > 0 && max-len(due) < room {
+		room = max - len(due)
+	}
+	c.mu.Lock()
+	next := c.pollNext % len(others)
+	c.pollNext = next + room
+	c.mu.Unlock()
+	for i := 0; i < room; i++ {
+		due = append(due, others[(next+i)%len(others)])
+	}
+	return due
+}
+
+// memberConversations lists the conversations the user is a member of.
+func (c *Plugin) memberConversations() ([]slack.Channel, error) {
+	params := &slack.GetConversationsForUserParameters{
+		UserID:          c.userID(),
+		Types:           []string{"public_channel", "private_channel", "mpim", "im"},
+		Limit:           200,
+		ExcludeArchived: true,
+	}
+	var all []slack.Channel
+	for {
+		channels, cursor, err := c.slackAPI().GetConversationsForUser(params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, channels...)
+		if cursor == "" {
+			return all, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+// setCursor records the timestamp of the last message polled from a conversation.
+func (c *Plugin) setCursor(channel, ts string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cursors == nil {
+		c.cursors = make(map[string]string)
+	}
+	c.cursors[channel] = ts
+}
+
+// timestamp formats t as a Slack message timestamp.
+func timestamp(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10) + "." + strconv.Itoa(t.Nanosecond()/1000 + 1000000)[1:]
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.conversations":
+			fmt.Fprint(w, `{"ok":true,"channels":[{"id":"C1"}]}`)
+		case "/conversations.history":
+			assert.Equal(t, "100.000000", r.FormValue("oldest"))
+			fmt.Fprint(w, `{"ok":true,"messages":[{"type":"message","text":"second","ts":"102.000000"},{"type":"message","text":"first","ts":"101.000000"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	config := &Config{APIURL: srv.URL, SlackToken: "xoxp-1"}
+	api, err := config.client()
+	assert.NoError(t, err)
+	c := &Plugin{api: api, config: config}
+	c.setCursor("C1", "100.000000")
+	events := make(chan slack.RTMEvent, 2)
+	assert.NoError(t, c.pollOnce(events, make(chan struct{}), 0))
+	if assert.Len(t, events, 2) {
+		first := (<-events).Data.(*slack.MessageEvent)
+		assert.Equal(t, "first", first.Text)
+		assert.Equal(t, "C1", first.Channel)
+		assert.Equal(t, "second", (<-events).Data.(*slack.MessageEvent).Text)
+	}
+	assert.Equal(t, "102.000000", c.cursors["C1"])
+}
+
+func TestPollDue(t *testing.T) {
+	c := &Plugin{config: &Config{PollPriority: []string{"@direct"}, PollSlowdown: 3, PollMaxConversations: 3}}
+	channels := []slack.Channel{
+		{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}},
+		{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "D1", IsIM: true}}},
+		{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C2"}}},
+		{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C3"}}},
+	}
+	ids := func(channels []slack.Channel) (ids []string) {
+		for _, ch := range channels {
+			ids = append(ids, ch.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"D1", "C1", "C2"}, ids(c.pollDue(channels, 0)))
+	assert.Equal(t, []string{"D1"}, ids(c.pollDue(channels, 1)))
+	assert.Equal(t, []string{"D1", "C3", "C1"}, ids(c.pollDue(channels, 3)))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// Notification levels of Slack's notification preferences.
+const (
+	levelEverything = "everything"
+	levelMentions   = "mentions"
+	levelNothing    = "nothing"
+)
+
+// notificationPrefs are the user's Slack notification preferences.
+type notificationPrefs struct {
+	global   string
+	channels map[string]string
+	muted    map[string]bool
+}
+
+// level returns the notification level for the conversation.
+func (p *notificationPrefs) level(channel string) string {
+	if l, ok := p.channels[channel]; ok {
+		return l
+	}
+	return p.global
+}
+
+// normalizeLevel maps Slack's preference values to a notification level,
+// returning an empty string for "default".
+func normalizeLevel(v string) string {
+	switch v {
+	case "all", levelEverything:
+		return levelEverything
+	case levelMentions:
+		return levelMentions
+	case levelNothing:
+		return levelNothing
+	}
+	return ""
+}
+
+// fetchPrefs reads the user's notification preferences with users.prefs.get.
+func (conf *Config) fetchPrefs() (*notificationPrefs, error) {
+	var resp struct {
+		Prefs struct {
+			AllNotificationsPrefs string `json:"all_notifications_prefs"`
+			MutedChannels         string `json:"muted_channels"`
+		} `json:"prefs"`
+	}
+	if err := conf.call("users.prefs.get", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	prefs, err := parsePrefs(resp.Prefs.AllNotificationsPrefs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range strings.Split(resp.Prefs.MutedChannels, ",") {
+		if id != "" {
+			prefs.muted[id] = true
+		}
+	}
+	return prefs, nil
+}
+
+// parsePrefs parses the JSON encoded all_notifications_prefs preference.
+// Mobile settings take precedence over desktop settings.
+func parsePrefs(raw string) (*notificationPrefs, error) {
+	var all struct {
+		Channels map[string]struct {
+			Desktop string `json:"desktop"`
+			Mobile  string `json:"mobile"`
+			Muted   bool   `json:"muted"`
+		} `json:"channels"`
+		Global struct {
+			Desktop string `json:"global_desktop"`
+			Mobile  string `json:"global_mobile"`
+		} `json:"global"`
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &all); err != nil {
+			return nil, err
+		}
+	}
+	prefs := &notificationPrefs{
+		global:   levelEverything,
+		channels: make(map[string]string),
+		muted:    make(map[string]bool),
+	}
+	if l := firstLevel(all.Global.Mobile, all.Global.Desktop); l != "" {
+		prefs.global = l
+	}
+	for id, ch := range all.Channels {
+		if ch.Muted {
+			prefs.muted[id] = true
+		}
+		if l := firstLevel(ch.Mobile, ch.Desktop); l != "" {
+			prefs.channels[id] = l
+		}
+	}
+	return prefs, nil
+}
+
+func firstLevel(values ...string) string {
+	for _, v := range values {
+		if l := normalizeLevel(v); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// loadPrefs refreshes the cached notification preferences if they are used.
+func (c *Plugin) loadPrefs() error {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	if !config.UseSlackPreferences && !config.RespectSlackMutes {
+		return nil
+	}
+	prefs, err := config.fetchPrefs()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.prefs = prefs
+	c.mu.Unlock()
+	return nil
+}
+
+// preferenceReason returns why the user's Slack preferences exclude the
+// message with the given raw text from forwarding, or an empty string if they don't.
+// As in Slack, direct messages count as mentions.
+func (c *Plugin) preferenceReason(channel *slack.Channel, text string) filterRule {
+	mentioned := channel.IsIM || c.mentionsMe(text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prefs == nil {
+		return ""
+	}
+	if c.config.RespectSlackMutes && c.prefs.muted[channel.ID] && !matchChannel(c.config.ForwardMutedChannels, channel) {
+		return ruleSlackMuted
+	}
+	if !c.config.UseSlackPreferences {
+		return ""
+	}
+	switch c.prefs.level(channel.ID) {
+	case levelNothing:
+		return ruleSlackPrefs
+	case levelMentions:
+		if !mentioned {
+			return ruleMentionsOnly
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrefs(t *testing.T) {
+	prefs, err := parsePrefs(`{"channels":{"C1":{"desktop":"nothing","mobile":"default"},"C2":{"desktop":"default","mobile":"mentions"},"C3":{"desktop":"default","mobile":"default","muted":true}},"global":{"global_desktop":"all","global_mobile":"default"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, levelNothing, prefs.level("C1"))
+	assert.Equal(t, levelMentions, prefs.level("C2"))
+	assert.Equal(t, levelEverything, prefs.level("C3"))
+	assert.Equal(t, levelEverything, prefs.level("D1"))
+	assert.True(t, prefs.muted["C3"])
+	assert.False(t, prefs.muted["C1"])
+}
+
+func TestMentionsOnlyPreference(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.config.UseSlackPreferences = true
+	c.prefs = &notificationPrefs{global: levelEverything, channels: map[string]string{"C1": levelMentions}, muted: map[string]bool{}}
+	c.users = map[string]cachedUser{
+		"U1": {user: &slack.User{ID: "U1", Name: "bob"}, fetched: time.Now()},
+		"U2": {user: &slack.User{ID: "U2", RealName: "Alice"}, fetched: time.Now()},
+	}
+	channel := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: "deploy done", Timestamp: "1.000000"}}, nil, channel)
+	assert.Empty(t, h.sent)
+	assert.Equal(t, filteredBy(string(ruleMentionsOnly)), c.recent[len(c.recent)-1].disposition)
+
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: "<@U1> please review", Timestamp: "2.000000"}}, nil, channel)
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | ops | Alice", h.sent[0].Title)
+	}
+}
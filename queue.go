@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// defaultQueueSize bounds the events waiting to be handled if QueueSize is not set.
+const defaultQueueSize = 1000
+
+// queueStats describes the load of the event queue.
+type queueStats struct {
+	waiting int
+	peak    int
+	// saturated counts how often the queue filled up, dropped counts the
+	// events dropped from it.
+	saturated     int
+	dropped       int
+	lastSaturated time.Time
+	warned        bool
+}
+
+// queueSettings returns the size and overflow policy of the event queue.
+func (c *Plugin) queueSettings() (size int, block bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	size = c.config.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return size, c.config.Overflow == "block"
+}
+
+// queue passes the events received from Slack in to the event loop through
+// out, keeping at most QueueSize of them waiting until stop is closed. If
+// the queue is full, the oldest waiting message is dropped or, with the
+// "block" Overflow policy, no more events are received until there is room.
+// Other events may change the connection's state and are only dropped if no
+// message is waiting.
+func (c *Plugin) queue(in <-chan slack.RTMEvent, out chan<- slack.RTMEvent, stop chan struct{}) {
+	size, block := c.queueSettings()
+	var waiting []slack.RTMEvent
+	// full is set from the queue filling up until there is room again, so
+	// that every time it fills up is counted once.
+	var full bool
+	for {
+		receive := in
+		if block && len(waiting) >= size {
+			receive = nil
+		}
+		var send chan<- slack.RTMEvent
+		var next slack.RTMEvent
+		if len(waiting) != 0 {
+			send, next = out, waiting[0]
+		}
+		select {
+		case <-stop:
+			return
+		case ev, ok := <-receive:
+			if !ok {
+				in = nil
+				continue
+			}
+			if len(waiting) >= size {
+				waiting = c.dropOldestMessage(waiting)
+			}
+			waiting = append(waiting, ev)
+			if len(waiting) >= size && !full {
+				full = true
+				c.saturated(block, time.Now())
+			}
+		case send <- next:
+			waiting = waiting[1:]
+			full = false
+		}
+		c.mu.Lock()
+		c.queueStats.waiting = len(waiting)
+		if len(waiting) > c.queueStats.peak {
+			c.queueStats.peak = len(waiting)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// saturated records that the event queue has filled up and warns about it
+// the first time, as messages are delayed or dropped from now on.
+func (c *Plugin) saturated(block bool, now time.Time) {
+	c.mu.Lock()
+	c.queueStats.saturated++
+	c.queueStats.lastSaturated = now
+	warn := !c.queueStats.warned
+	c.queueStats.warned = true
+	l, priority := c.config.Locale, c.config.WarningPriority
+	c.mu.Unlock()
+	if !warn {
+		return
+	}
+	text := "Slack events arrive faster than they can be handled, so the oldest waiting messages are dropped. See the plugin's page for the queue's load."
+	if block {
+		text = "Slack events arrive faster than they can be handled, so messages are delayed. See the plugin's page for the queue's load."
+	}
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Event queue full")),
+		Message:  tr(l, text),
+		Priority: priority,
+	})
+}
+
+// queueStatus describes the load of the event queue for the plugin's page.
+func (c *Plugin) queueStatus(l string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := fmt.Sprintf(tr(l, "%d waiting, peak %d, full %d times, %d dropped"),
+		c.queueStats.waiting, c.queueStats.peak, c.queueStats.saturated, c.queueStats.dropped)
+	if !c.queueStats.lastSaturated.IsZero() {
+		s += fmt.Sprintf(" (%s %s)", tr(l, "last"), c.queueStats.lastSaturated.Format("2006-01-02 15:04"))
+	}
+	return s
+}
+
+// dropOldestMessage removes the oldest message event from waiting and counts
+// it, or the oldest event if no message is waiting.
+func (c *Plugin) dropOldestMessage(waiting []slack.RTMEvent) []slack.RTMEvent {
+	for i, ev := range waiting {
+		if _, ok := ev.Data.(*slack.MessageEvent); ok {
+			c.countDropped(ev)
+			return append(waiting[:i], waiting[i+1:]...)
+		}
+	}
+	if len(waiting) == 0 {
+		return waiting
+	}
+	c.logln("event queue full, dropping", waiting[0].Type, "event")
+	c.countDropped(waiting[0])
+	return waiting[1:]
+}
+
+// countDropped counts an event dropped under load. Dropped messages are
+// also counted for the overflow summary.
+func (c *Plugin) countDropped(ev slack.RTMEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queueStats.dropped++
+	if _, ok := ev.Data.(*slack.MessageEvent); ok {
+		c.overflowed++
+	}
+}
+
+// sendOverflowSummary tells the user how many messages were dropped
+// because the event queue was full.
+func (c *Plugin) sendOverflowSummary() {
+	c.mu.Lock()
+	n := c.overflowed
+	c.overflowed = 0
+	l := c.config.Locale
+	c.mu.Unlock()
+	if n == 0 {
+		return
+	}
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Overload")),
+		Message:  fmt.Sprintf(tr(l, "+%d Slack messages dropped under load, see Slack"), n),
+		Priority: c.defaultPriority(),
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueDropsOldestMessage(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.QueueSize = 3
+	in, out, stop := make(chan slack.RTMEvent), make(chan slack.RTMEvent), make(chan struct{})
+	defer close(stop)
+	go c.queue(in, out, stop)
+
+	message := func(ts string) slack.RTMEvent {
+		return slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: slack.Msg{Timestamp: ts}}}
+	}
+	in <- slack.RTMEvent{Type: "hello", Data: &slack.HelloEvent{}}
+	in <- message("1")
+	in <- message("2")
+	in <- message("3")
+	// The event loop only starts reading now.
+	assert.IsType(t, &slack.HelloEvent{}, (<-out).Data)
+	assert.Equal(t, "2", (<-out).Data.(*slack.MessageEvent).Timestamp)
+	assert.Equal(t, "3", (<-out).Data.(*slack.MessageEvent).Timestamp)
+
+	c.sendOverflowSummary()
+	if assert.Len(t, h.sent, 2) {
+		assert.Equal(t, "Slack | Event queue full", h.sent[0].Title)
+		assert.Equal(t, "+1 Slack messages dropped under load, see Slack", h.sent[1].Message)
+	}
+	c.sendOverflowSummary()
+	assert.Len(t, h.sent, 2)
+	c.mu.Lock()
+	stats := c.queueStats
+	c.mu.Unlock()
+	assert.Equal(t, 3, stats.peak)
+	assert.Equal(t, 1, stats.saturated)
+	assert.Equal(t, 1, stats.dropped)
+	assert.Contains(t, c.queueStatus(""), "peak 3, full 1 times, 1 dropped")
+}
+
+func TestQueueDropsOldestEvent(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.QueueSize = 2
+	in, out, stop := make(chan slack.RTMEvent), make(chan slack.RTMEvent), make(chan struct{})
+	defer close(stop)
+	go c.queue(in, out, stop)
+
+	in <- slack.RTMEvent{Type: "hello", Data: &slack.HelloEvent{}}
+	in <- slack.RTMEvent{Type: "user_typing", Data: &slack.UserTypingEvent{}}
+	in <- slack.RTMEvent{Type: "presence_change", Data: &slack.PresenceChangeEvent{}}
+	assert.Equal(t, "user_typing", (<-out).Type)
+	assert.Equal(t, "presence_change", (<-out).Type)
+	c.mu.Lock()
+	assert.Equal(t, 2, c.queueStats.peak)
+	assert.Equal(t, 1, c.queueStats.dropped)
+	assert.Zero(t, c.overflowed)
+	c.mu.Unlock()
+}
+
+func TestQueueBlocks(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.QueueSize = 1
+	c.config.Overflow = "block"
+	in, out, stop := make(chan slack.RTMEvent), make(chan slack.RTMEvent), make(chan struct{})
+	defer close(stop)
+	go c.queue(in, out, stop)
+
+	in <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{}}
+	select {
+	case in <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{}}:
+		t.Fatal("the full queue accepted an event")
+	default:
+	}
+	<-out
+	in <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{}}
+	<-out
+	assert.Zero(t, c.overflowed)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+)
+
+// quietOverride holds quiet hours set with the quiet command.
+type quietOverride struct {
+	Window Window `json:"window"`
+	// Base is the configured quiet hours the override replaces. Changing
+	// them in the config ends the override.
+	Base Window `json:"base"`
+}
+
+// quietHours returns the quiet hours in effect. c.mu must be held.
+func (c *Plugin) quietHours() Window {
+	if c.quiet != nil && reflect.DeepEqual(c.quiet.Base, c.config.QuietHours) {
+		return c.quiet.Window
+	}
+	return c.config.QuietHours
+}
+
+// setQuietHours overrides the configured quiet hours with w and persists
+// the override.
+func (c *Plugin) setQuietHours(w Window) error {
+	c.mu.Lock()
+	quiet := &quietOverride{Window: w, Base: c.config.QuietHours}
+	c.quiet = quiet
+	c.mu.Unlock()
+	return c.updateState(func(s *storedState) { s.Quiet = quiet })
+}
+
+// loadQuietHours restores the quiet hours set with the quiet command.
+func (c *Plugin) loadQuietHours() error {
+	state, err := c.loadState()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.quiet = state.Quiet
+	c.mu.Unlock()
+	return nil
+}
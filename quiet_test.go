@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietCommand(t *testing.T) {
+	storage := &memoryStorage{}
+	c := &Plugin{uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.SetStorageHandler(storage)
+	configured := c.config
+	assert.Equal(t, "Quiet hours: 22:00-07:00", c.runCommand(slack.SlashCommand{UserID: "U1", Text: "quiet 22:00-07:00"}))
+	assert.True(t, configured.QuietHours.Empty())
+	assert.Equal(t, Window{Start: "22:00", End: "07:00"}, c.quietHours())
+
+	// The quiet hours survive a restart.
+	restarted := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	restarted.SetStorageHandler(storage)
+	assert.NoError(t, restarted.loadQuietHours())
+	assert.Equal(t, Window{Start: "22:00", End: "07:00"}, restarted.quietHours())
+
+	// Changing the quiet hours in the config ends the override.
+	restarted.config.QuietHours = Window{Start: "23:00", End: "06:00"}
+	assert.Equal(t, Window{Start: "23:00", End: "06:00"}, restarted.quietHours())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// recentSize is the number of handled messages kept for the display page.
+const recentSize = 50
+
+const (
+	dispositionForwarded = "forwarded"
+	dispositionDryRun    = "would forward"
+	// dispositionDigestOnly counts a message only in the daily digest.
+	dispositionDigestOnly = "digest only"
+)
+
+// filterRule is a rule that filtered a message, as shown on the plugin's
+// page. Code compares against the constants, never the wording.
+type filterRule string
+
+const (
+	ruleMuted        filterRule = "muted"
+	ruleQuietHours   filterRule = "quiet hours"
+	ruleSlackMuted   filterRule = "muted in Slack"
+	ruleSlackPrefs   filterRule = "Slack preferences"
+	ruleMentionsOnly filterRule = "Slack preferences (mentions only)"
+)
+
+// filteredBy returns the disposition of a message dropped by rule.
+func filteredBy(rule string) string {
+	return "filtered by " + rule
+}
+
+// logEntry records what happened to a Slack message.
+type logEntry struct {
+	time        time.Time
+	title       string
+	disposition string
+}
+
+// record updates the statistics and the recent-messages log for a message
+// from the given conversation. c.mu must not be held.
+func (c *Plugin) record(conversation, title, disposition string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case disposition == dispositionForwarded:
+		c.stats.forwarded++
+		if c.activity == nil {
+			c.activity = make(map[string]*activity)
+		}
+		if c.activity[conversation] == nil {
+			c.activity[conversation] = &activity{}
+		}
+		c.activity[conversation].add(time.Now())
+	case strings.HasPrefix(disposition, dispositionDryRun):
+	case strings.HasPrefix(disposition, "error"):
+		c.stats.errors++
+	default:
+		c.stats.filtered++
+	}
+	c.countDigest(conversation, disposition)
+	c.recent = append(c.recent, logEntry{time: time.Now(), title: title, disposition: disposition})
+	if len(c.recent) > recentSize {
+		c.recent = c.recent[len(c.recent)-recentSize:]
+	}
+}
+
+// recentTable renders the recent-messages log as a markdown table, newest first.
+func (c *Plugin) recentTable(l string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.recent) == 0 {
+		return tr(l, "No messages yet.") + "\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | %s | %s |\n|---|---|---|\n", tr(l, "Time"), tr(l, "Title"), tr(l, "Disposition"))
+	for i := len(c.recent) - 1; i >= 0; i-- {
+		e := c.recent[i]
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", e.time.Format("15:04:05"), escapeCell(e.title), escapeCell(e.disposition))
+	}
+	return b.String()
+}
+
+// escapeCell makes s safe to use in a markdown table cell.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// panicNoticeInterval limits how often panics are notified, in case every event panics.
+const panicNoticeInterval = 10 * time.Minute
+
+// runEventLoop runs the event loop, recovering from panics so that a bug in
+// handling a single event neither takes down gotify nor silently stops
+// forwarding. panicked tells whether the loop has to be restarted.
+func (c *Plugin) runEventLoop(events <-chan slack.RTMEvent, done chan struct{}) (panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.recovered(r)
+			panicked = true
+		}
+	}()
+	return false, c.eventLoop(events, done)
+}
+
+// safely runs f, recovering from panics like the event loop does. Timer
+// callbacks and goroutines the plugin starts run through it, as a panic in
+// them would otherwise take down gotify.
+func (c *Plugin) safely(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.recovered(r)
+		}
+	}()
+	f()
+}
+
+// recovered logs a recovered panic with its stack and notifies about it.
+func (c *Plugin) recovered(r interface{}) {
+	c.logln(fmt.Sprintf("panic: %v\n%s", r, debug.Stack()))
+	c.mu.Lock()
+	notify := time.Since(c.panicNotified) >= panicNoticeInterval
+	if notify {
+		c.panicNotified = time.Now()
+	}
+	c.stats.errors++
+	l, priority := c.config.Locale, c.config.ErrorPriority
+	c.mu.Unlock()
+	if !notify {
+		return
+	}
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Internal error")),
+		Message:  fmt.Sprintf(tr(l, "An internal error occurred: %v. Forwarding continues, see the log for details."), r),
+		Priority: priority,
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunEventLoopRecovers(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{config: &Config{}, msgHandler: h}
+	// Without a connection the loop panics on its first message.
+	events := make(chan slack.RTMEvent, 2)
+	events <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: slack.Msg{Channel: "C1"}}}
+	events <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{Msg: slack.Msg{Channel: "C1"}}}
+	panicked, _ := c.runEventLoop(events, make(chan struct{}))
+	assert.True(t, panicked)
+	panicked, _ = c.runEventLoop(events, make(chan struct{}))
+	assert.True(t, panicked)
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Internal error", h.sent[0].Title)
+	}
+}
+
+func TestSafely(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{config: &Config{}, msgHandler: h}
+	done := make(chan struct{})
+	time.AfterFunc(0, func() {
+		defer close(done)
+		c.safely(func() { panic("boom") })
+	})
+	<-done
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "An internal error occurred: boom. Forwarding continues, see the log for details.", h.sent[0].Message)
+	}
+	assert.Equal(t, 1, c.stats.errors)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gotify/plugin-api"
+)
+
+const redacted = "[redacted]"
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ibanRe  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`)
+	cardRe  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	phoneRe = regexp.MustCompile(`(?:\+\d|\b0)[\d ()/.-]{6,}\d`)
+)
+
+// scrubPII masks email addresses, IBANs, credit card numbers and phone numbers in s.
+func scrubPII(s string) string {
+	s = emailRe.ReplaceAllLiteralString(s, "[email]")
+	s = ibanRe.ReplaceAllLiteralString(s, "[IBAN]")
+	s = cardRe.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return "[card]"
+		}
+		return m
+	})
+	return phoneRe.ReplaceAllStringFunc(s, func(m string) string {
+		if n := countDigits(m); n >= 8 && n <= 15 {
+			return "[phone]"
+		}
+		return m
+	})
+}
+
+// luhn reports whether the digits in s pass the Luhn check of card numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func countDigits(s string) int {
+	return len(s) - len(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// validateRedactions checks whether the redaction patterns compile.
+func validateRedactions(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := cachedRegexp(p); err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// redact replaces the matches of the configured redaction patterns in the
+// title and body of msg, so that e.g. tokens never reach a lock screen,
+// and masks personal data in the body if ScrubPII is set.
+func (c *Plugin) redact(msg *plugin.Message) {
+	c.mu.Lock()
+	patterns, scrub := c.config.Redact, c.config.ScrubPII
+	c.mu.Unlock()
+	if scrub {
+		msg.Message = scrubPII(msg.Message)
+	}
+	for _, p := range patterns {
+		re, err := cachedRegexp(p)
+		if err != nil {
+			continue
+		}
+		msg.Title = re.ReplaceAllLiteralString(msg.Title, redacted)
+		msg.Message = re.ReplaceAllLiteralString(msg.Message, redacted)
+	}
+}
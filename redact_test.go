@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gotify/plugin-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	c := &Plugin{config: &Config{Redact: []string{`xox[abp]-[0-9A-Za-z-]+`, `CUST-\d+`}}}
+	msg := plugin.Message{Title: "Slack | CUST-42", Message: "token xoxb-123-abc for CUST-42"}
+	c.redact(&msg)
+	assert.Equal(t, "Slack | [redacted]", msg.Title)
+	assert.Equal(t, "token [redacted] for [redacted]", msg.Message)
+	assert.Error(t, validateRedactions([]string{"("}))
+}
+
+func TestScrubPII(t *testing.T) {
+	assert.Equal(t, "mail [email] or call [phone]", scrubPII("mail jane.doe@example.com or call +49 30 1234567"))
+	assert.Equal(t, "card [card], not 1234 5678 9012 3456", scrubPII("card 4111 1111 1111 1111, not 1234 5678 9012 3456"))
+	assert.Equal(t, "pay to [IBAN] now", scrubPII("pay to DE89 3704 0044 0532 0130 00 now"))
+	assert.Equal(t, "released 2024-01-02, build 42", scrubPII("released 2024-01-02, build 42"))
+	assert.Equal(t, "call [phone] today", scrubPII("call 030 1234 5678 today"))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// reminderPrefix starts the messages Slackbot posts for due reminders,
+// including those of items saved for later.
+const reminderPrefix = "Reminder: "
+
+// permalinkRe matches a message permalink, capturing the channel and the
+// timestamp's seconds and microseconds.
+var permalinkRe = regexp.MustCompile(`https://[^/\s|>]+/archives/([A-Z0-9]+)/p(\d{10})(\d{6})`)
+
+// handleReminder forwards a due reminder Slackbot posted in a direct
+// conversation with the text and permalink of the message saved for
+// later. It reports whether msg was such a reminder.
+func (c *Plugin) handleReminder(msg *slack.Msg, channel *slack.Channel) bool {
+	c.mu.Lock()
+	enabled := c.config.NotifyReminders
+	c.mu.Unlock()
+	if !enabled || !channel.IsIM || msg.User != slackbotUser || !strings.HasPrefix(msg.Text, reminderPrefix) {
+		return false
+	}
+	text := strings.TrimPrefix(msg.Text, reminderPrefix)
+	var link string
+	if m := permalinkRe.FindStringSubmatch(text); m != nil {
+		link = m[0]
+		if saved := c.savedText(m[1], m[2]+"."+m[3]); saved != "" {
+			text = saved
+		}
+	}
+	l := c.locale()
+	if c.plainFormat() {
+		text = plainText(text)
+	}
+	text = unescape(text)
+	if link != "" && !strings.Contains(text, link) {
+		text += "\n" + link
+	}
+	out := plugin.Message{
+		Title:    c.title(titleParts{team: c.teamName(""), channel: tr(l, "Reminder")}),
+		Message:  text,
+		Priority: c.defaultPriority(),
+	}
+	if link != "" {
+		setExtra(&out, "client::notification", "click", map[string]string{"url": link})
+	}
+	c.deliver(channel, "@Slackbot", out)
+	return true
+}
+
+// savedText returns the text of the message posted at ts in channel, or an
+// empty string if it cannot be fetched.
+func (c *Plugin) savedText(channel, ts string) string {
+	history, err := c.slackAPI().GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: channel, Latest: ts, Inclusive: true, Limit: 1})
+	if err != nil {
+		c.logln(err)
+		return ""
+	}
+	if len(history.Messages) == 0 || history.Messages[0].Timestamp != ts {
+		return ""
+	}
+	return history.Messages[0].Text
+}
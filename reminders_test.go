@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleReminder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/conversations.history", r.URL.Path)
+		assert.Equal(t, "C1", r.FormValue("channel"))
+		assert.Equal(t, "1700000000.123456", r.FormValue("latest"))
+		fmt.Fprint(w, `{"ok":true,"messages":[{"type":"message","text":"Please review &lt;PR 42&gt;","ts":"1700000000.123456"}]}`)
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+	dm := &slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "D1", IsIM: true}}}
+	link := "https://acme.slack.com/archives/C1/p1700000000123456"
+
+	assert.False(t, c.handleReminder(&slack.Msg{User: "U2", Text: "Reminder: " + link}, dm))
+	assert.True(t, c.handleReminder(&slack.Msg{User: slackbotUser, Text: "Reminder: <" + link + ">"}, dm))
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme | Reminder", h.sent[0].Title)
+		assert.Equal(t, "Please review <PR 42>\n"+link, h.sent[0].Message)
+		assert.Equal(t, map[string]string{"url": link}, h.sent[0].Extras["client::notification"].(map[string]interface{})["click"])
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// refreshMargin is how long before its expiry a rotated token is refreshed.
+const refreshMargin = time.Hour
+
+// rotatedToken is an access token obtained with token rotation.
+type rotatedToken struct {
+	// Origin is the configured refresh token the rotations started from,
+	// so that configuring another one discards the stored tokens.
+	Origin       string    `json:"origin"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// refresh exchanges a refresh token for a new access token with oauth.v2.access.
+func (conf *Config) refresh(refreshToken string, now time.Time) (*rotatedToken, error) {
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	values := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {conf.ClientID},
+		"client_secret": {conf.ClientSecret},
+	}
+	if err := conf.post("oauth.v2.access", values, "", &resp); err != nil {
+		return nil, fmt.Errorf("refreshing the token: %v", err)
+	}
+	return &rotatedToken{
+		Token:        resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		Expiry:       now.Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// rotate returns a copy of config with the current rotated access token and
+// its expiry, refreshing and persisting it if it expires within refreshMargin.
+// Configs without a refresh token are returned as they are.
+func (c *Plugin) rotate(config *Config, now time.Time) (*Config, time.Time, error) {
+	if config.RefreshToken == "" {
+		return config, time.Time{}, nil
+	}
+	state, err := c.loadState()
+	if err != nil {
+		c.logln(err)
+	}
+	tok := state.Token
+	if tok == nil || tok.Origin != config.RefreshToken {
+		tok = &rotatedToken{Origin: config.RefreshToken, RefreshToken: config.RefreshToken}
+	}
+	if tok.Token == "" || now.After(tok.Expiry.Add(-refreshMargin)) {
+		fresh, err := config.refresh(tok.RefreshToken, now)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		fresh.Origin = tok.Origin
+		tok = fresh
+		if err := c.updateState(func(s *storedState) { s.Token = tok }); err != nil {
+			c.logln(err)
+		}
+	}
+	rotated := *config
+	rotated.SlackToken = tok.Token
+	return &rotated, tok.Expiry, nil
+}
+
+// refreshConfig updates the current config with the latest rotated token.
+func (c *Plugin) refreshConfig() error {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	rotated, expiry, err := c.rotate(config, time.Now())
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.config = rotated
+	c.tokenExpiry = expiry
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStorage struct {
+	data []byte
+}
+
+func (s *memoryStorage) Save(b []byte) error {
+	s.data = b
+	return nil
+}
+
+func (s *memoryStorage) Load() ([]byte, error) {
+	return s.data, nil
+}
+
+func TestRotate(t *testing.T) {
+	refreshes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		assert.Equal(t, "/oauth.v2.access", r.URL.Path)
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+		fmt.Fprintf(w, `{"ok":true,"access_token":"xoxe.xoxp-%d","refresh_token":"xoxe-%d","expires_in":43200}`, refreshes, refreshes)
+	}))
+	defer srv.Close()
+
+	c := &Plugin{}
+	c.SetStorageHandler(&memoryStorage{})
+	config := &Config{APIURL: srv.URL, RefreshToken: "xoxe-0", ClientID: "id", ClientSecret: "secret"}
+	now := time.Now()
+
+	rotated, expiry, err := c.rotate(config, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxe.xoxp-1", rotated.SlackToken)
+	assert.Equal(t, now.Add(12*time.Hour), expiry)
+	assert.Equal(t, "", config.SlackToken)
+
+	rotated, _, err = c.rotate(config, now.Add(10*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxe.xoxp-1", rotated.SlackToken)
+	assert.Equal(t, 1, refreshes)
+
+	rotated, _, err = c.rotate(config, now.Add(11*time.Hour+time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxe.xoxp-2", rotated.SlackToken)
+	state, _ := c.loadState()
+	assert.Equal(t, "xoxe-2", state.Token.RefreshToken)
+	assert.Equal(t, "xoxe-0", state.Token.Origin)
+}
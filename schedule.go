@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a time window given as "HH:MM" start and end times.
+// A window whose end lies before its start spans midnight.
+// Days ("Saturday", "sun", ...) and Dates ("2006-01-02", e.g. holidays)
+// restrict the window to those days; without start and end times the
+// window then covers the whole day.
+// The zero value never matches.
+type Window struct {
+	Start string
+	End   string
+	Days  []string
+	Dates []string
+}
+
+// Empty reports whether the window is unset.
+func (w Window) Empty() bool {
+	return w.Start == "" && w.End == "" && len(w.Days) == 0 && len(w.Dates) == 0
+}
+
+// Validate checks whether the bounds, days and dates of the window can be parsed.
+func (w Window) Validate() error {
+	for _, d := range w.Days {
+		if _, err := parseWeekday(d); err != nil {
+			return err
+		}
+	}
+	for _, d := range w.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+	if w.Start == "" && w.End == "" {
+		return nil
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	_, err := parseClock(w.End)
+	return err
+}
+
+// Contains reports whether t lies within the window. The part of an
+// overnight window after midnight belongs to the day the window started.
+func (w Window) Contains(t time.Time) bool {
+	if w.Empty() {
+		return false
+	}
+	if w.Start == "" && w.End == "" {
+		return w.matchesDay(t)
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return now >= start && now < end && w.matchesDay(t)
+	}
+	if now >= start {
+		return w.matchesDay(t)
+	}
+	return now < end && w.matchesDay(t.AddDate(0, 0, -1))
+}
+
+func (w Window) matchesDay(t time.Time) bool {
+	if len(w.Days) == 0 && len(w.Dates) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if wd, err := parseWeekday(d); err == nil && wd == t.Weekday() {
+			return true
+		}
+	}
+	date := t.Format("2006-01-02")
+	for _, d := range w.Dates {
+		if d == date {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Window) String() string {
+	if w.Empty() {
+		return "off"
+	}
+	var s string
+	if w.Start != "" || w.End != "" {
+		s = w.Start + "-" + w.End
+	} else {
+		s = "all day"
+	}
+	if days := append(append([]string{}, w.Days...), w.Dates...); len(days) != 0 {
+		s += " on " + strings.Join(days, ", ")
+	}
+	return s
+}
+
+// Profile restricts notifications during a time of day,
+// e.g. only priority 7 and above in the evening or only direct messages at night.
+// Priority, if set, replaces the priority of the notifications passing it.
+type Profile struct {
+	Name        string
+	Window      Window
+	MinPriority int
+	DirectOnly  bool
+	Priority    int
+}
+
+// activeProfile returns the first profile whose window contains t.
+func activeProfile(profiles []Profile, t time.Time) *Profile {
+	for i := range profiles {
+		if profiles[i].Window.Contains(t) {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// parseWeekday parses a full or three-letter English weekday name.
+func parseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
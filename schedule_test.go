@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(clock string) time.Time {
+	tm, _ := time.Parse("15:04", clock)
+	return tm
+}
+
+func TestWindowContains(t *testing.T) {
+	day := Window{Start: "09:00", End: "17:00"}
+	assert.True(t, day.Contains(at("09:00")))
+	assert.False(t, day.Contains(at("17:00")))
+	night := Window{Start: "22:00", End: "07:00"}
+	assert.True(t, night.Contains(at("23:30")))
+	assert.True(t, night.Contains(at("06:59")))
+	assert.False(t, night.Contains(at("12:00")))
+	assert.False(t, Window{}.Contains(at("12:00")))
+}
+
+func TestWindowValidate(t *testing.T) {
+	assert.NoError(t, Window{}.Validate())
+	assert.NoError(t, Window{Start: "22:00", End: "07:00"}.Validate())
+	assert.Error(t, Window{Start: "25:00", End: "07:00"}.Validate())
+}
+
+func TestActiveProfile(t *testing.T) {
+	profiles := []Profile{
+		{Name: "evening", Window: Window{Start: "18:00", End: "22:00"}, MinPriority: 7},
+		{Name: "night", Window: Window{Start: "22:00", End: "07:00"}, DirectOnly: true},
+	}
+	assert.Nil(t, activeProfile(profiles, at("12:00")))
+	assert.Equal(t, "evening", activeProfile(profiles, at("19:00")).Name)
+	assert.Equal(t, "night", activeProfile(profiles, at("03:00")).Name)
+}
+
+func TestApplyProfile(t *testing.T) {
+	c := &Plugin{config: &Config{Profiles: []Profile{
+		{Name: "evening", Window: Window{Start: "18:00", End: "22:00"}, MinPriority: 7, Priority: 4},
+		{Name: "night", Window: Window{Start: "22:00", End: "07:00"}, DirectOnly: true},
+	}}}
+	priority, reason := c.applyProfile(5, false, at("12:00"))
+	assert.Equal(t, 5, priority)
+	assert.Empty(t, reason)
+	_, reason = c.applyProfile(5, false, at("19:00"))
+	assert.Equal(t, "profile evening", reason)
+	priority, reason = c.applyProfile(8, false, at("19:00"))
+	assert.Equal(t, 4, priority)
+	assert.Empty(t, reason)
+	priority, reason = c.applyProfile(8, true, at("03:00"))
+	assert.Equal(t, 8, priority)
+	assert.Empty(t, reason)
+}
+
+func TestWindowDays(t *testing.T) {
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	weekend := Window{Days: []string{"Saturday", "sun"}}
+	assert.NoError(t, weekend.Validate())
+	assert.True(t, weekend.Contains(saturday))
+	assert.False(t, weekend.Contains(monday))
+	holiday := Window{Dates: []string{"2026-10-19"}, Start: "08:00", End: "18:00"}
+	assert.True(t, holiday.Contains(monday))
+	assert.False(t, holiday.Contains(saturday))
+	assert.Error(t, Window{Days: []string{"Caturday"}}.Validate())
+
+	// The hours after midnight belong to the day an overnight window started.
+	friday := Window{Days: []string{"fri"}, Start: "22:00", End: "06:00"}
+	assert.True(t, friday.Contains(time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, friday.Contains(time.Date(2026, 10, 17, 5, 0, 0, 0, time.UTC)))
+	assert.False(t, friday.Contains(time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)))
+	assert.False(t, friday.Contains(time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// sender is the author of a Slack message, a user or a bot.
+type sender struct {
+	id   string
+	name string
+	bot  bool
+}
+
+// resolveSender looks up the author of msg. Bot messages carry no user,
+// so their sender is named after the message's username or the bot.
+// Users the account may not see, e.g. as a guest, are named by their ID.
+func (c *Plugin) resolveSender(msg *slack.Msg) (*sender, error) {
+	if msg.SubType != "bot_message" && msg.User != "" {
+		user, err := c.userInfo(msg.User)
+		if err != nil && isRestrictedError(err) {
+			return &sender{id: msg.User, name: msg.User}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &sender{id: user.ID, name: c.userName(user), bot: user.IsBot}, nil
+	}
+	if msg.BotID == "" && msg.Username == "" {
+		return nil, errors.New("message without sender")
+	}
+	s := &sender{id: msg.BotID, name: msg.Username, bot: true}
+	if s.name == "" {
+		s.name = c.botName(msg.BotID)
+	}
+	return s, nil
+}
+
+// userName names a user by the profile field selected with NameSource.
+// Deleted users and restricted profiles may lack it, so it falls back to
+// the real name, the display name, the username and finally the user's ID.
+func (c *Plugin) userName(user *slack.User) string {
+	c.mu.Lock()
+	source := c.config.NameSource
+	c.mu.Unlock()
+	var preferred string
+	switch source {
+	case "displayname":
+		preferred = user.Profile.DisplayName
+	case "username":
+		preferred = user.Name
+	default:
+		preferred = user.RealName
+	}
+	for _, name := range []string{preferred, user.RealName, user.Profile.DisplayName, user.Name, user.ID} {
+		if strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// botName returns the name of a bot, falling back to its ID.
+func (c *Plugin) botName(id string) string {
+	c.mu.Lock()
+	name, ok := c.botNames[id]
+	c.mu.Unlock()
+	if ok {
+		return name
+	}
+	bot, err := c.slackAPI().GetBotInfo(id)
+	if err != nil && !isRestrictedError(err) {
+		return id
+	}
+	name = id
+	if err == nil && bot.Name != "" {
+		name = bot.Name
+	}
+	c.mu.Lock()
+	if c.botNames == nil {
+		c.botNames = make(map[string]string)
+	}
+	c.botNames[id] = name
+	c.mu.Unlock()
+	return name
+}
+
+// forwardOwn reports whether the user's own messages are forwarded.
+func (c *Plugin) forwardOwn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.ForwardOwn
+}
+
+// botReason returns why messages of a bot are not forwarded, or an empty string if they are.
+func (c *Plugin) botReason(s *sender) string {
+	if !s.bot {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.config.ForwardBots {
+		return "bots"
+	}
+	for _, b := range c.config.IgnoredBots {
+		if b == s.id || strings.EqualFold(b, s.name) {
+			return "ignored bot"
+		}
+	}
+	return ""
+}
+
+// slackbotUser is the user ID of Slackbot.
+const slackbotUser = "USLACKBOT"
+
+// slackbotReason returns why a message of Slackbot is not forwarded, or an
+// empty string if it is. direct tells whether it was sent in a direct conversation.
+func (c *Plugin) slackbotReason(s *sender, direct bool) string {
+	if s.id != slackbotUser {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.config.Slackbot {
+	case "none":
+		return "Slackbot"
+	case "direct":
+		if !direct {
+			return "Slackbot response"
+		}
+	}
+	return ""
+}
+
+// attachmentText renders an attachment, preferring its fallback text.
+func attachmentText(att slack.Attachment) string {
+	if att.Fallback != "" {
+		return att.Fallback
+	}
+	var lines []string
+	for _, s := range []string{att.Pretext, att.AuthorName, att.Title, att.Text} {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	for _, f := range att.Fields {
+		lines = append(lines, f.Title+": "+f.Value)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSender(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.botNames = map[string]string{"B1": "CI"}
+	s, err := c.resolveSender(&slack.Msg{SubType: "bot_message", User: "U1", BotID: "B1", Username: "deploy"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &sender{id: "B1", name: "deploy", bot: true}, s)
+	}
+	s, err = c.resolveSender(&slack.Msg{SubType: "bot_message", BotID: "B1"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &sender{id: "B1", name: "CI", bot: true}, s)
+	}
+	_, err = c.resolveSender(&slack.Msg{SubType: "bot_message"})
+	assert.Error(t, err)
+}
+
+func TestBotReason(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	bot := &sender{id: "B1", name: "CI", bot: true}
+	assert.Equal(t, "", c.botReason(&sender{id: "U1", name: "Alice"}))
+	assert.Equal(t, "", c.botReason(bot))
+	c.config.ForwardBots = false
+	assert.Equal(t, "bots", c.botReason(bot))
+	c.config.ForwardBots = true
+	c.config.IgnoredBots = []string{"ci"}
+	assert.Equal(t, "ignored bot", c.botReason(bot))
+}
+
+func TestUserName(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	user := &slack.User{Name: "jdoe", RealName: "Doe, John (Sales)", Profile: slack.UserProfile{DisplayName: "John"}}
+	assert.Equal(t, "Doe, John (Sales)", c.userName(user))
+	c.config.NameSource = "displayname"
+	assert.Equal(t, "John", c.userName(user))
+	c.config.NameSource = "username"
+	assert.Equal(t, "jdoe", c.userName(user))
+}
+
+func TestUserNameFallback(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	assert.Equal(t, "John", c.userName(&slack.User{ID: "U1", Name: "jdoe", Profile: slack.UserProfile{DisplayName: "John"}}))
+	assert.Equal(t, "jdoe", c.userName(&slack.User{ID: "U1", Name: "jdoe", RealName: " "}))
+	assert.Equal(t, "U1", c.userName(&slack.User{ID: "U1", Deleted: true}))
+	c.config.NameSource = "username"
+	assert.Equal(t, "Jane Doe", c.userName(&slack.User{ID: "U2", RealName: "Jane Doe"}))
+}
+
+func TestMentionText(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	user := &slack.User{ID: "U1", Name: "jdoe", RealName: "Doe, John", Profile: slack.UserProfile{DisplayName: "John"}}
+	assert.Equal(t, "<@Doe, John>", c.mentionText(user))
+	c.config.NameSource = "displayname"
+	c.config.MentionFormat = "at"
+	assert.Equal(t, "@John", c.mentionText(user))
+	c.config.MentionFormat = "bold"
+	assert.Equal(t, "**@John**", c.mentionText(user))
+	c.config.MentionFormat = "name"
+	assert.Equal(t, "John", c.mentionText(user))
+	c.config.MentionFormat = "none"
+	assert.Equal(t, "", c.mentionText(user))
+}
+
+func TestReplaceMention(t *testing.T) {
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id := r.Form.Get("user")
+		calls[id]++
+		switch {
+		case id == "U1" && calls[id] == 1:
+			fmt.Fprint(w, `{"ok":false,"error":"internal_error"}`)
+		case id == "U1":
+			fmt.Fprint(w, `{"ok":true,"user":{"id":"U1","real_name":"Alice"}}`)
+		case id == "U2":
+			fmt.Fprint(w, `{"ok":false,"error":"user_not_visible"}`)
+		default:
+			fmt.Fprint(w, `{"ok":false,"error":"internal_error"}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+	assert.Equal(t, "<@Alice>", c.replaceMention("<@U1>"))
+	assert.Equal(t, "<@U2>", c.replaceMention("<@U2>"))
+	assert.Equal(t, 1, calls["U2"])
+	assert.Equal(t, "<@bob>", c.replaceMention("<@U3|bob>"))
+	assert.Equal(t, 2, calls["U3"])
+	c.config.MentionFormat = "bold"
+	assert.Equal(t, "**@bob**", c.replaceMention("<@U3|bob>"))
+	c.config.MentionFormat = "none"
+	assert.Equal(t, "", c.replaceMention("<@U2>"))
+}
+
+func TestForwardOwn(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.users = map[string]cachedUser{"U1": {user: &slack.User{ID: "U1", RealName: "Alice"}, fetched: time.Now()}}
+	channel := &slack.Channel{}
+	channel.ID = "C1"
+	channel.Name = "general"
+	ev := &slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U1", Text: "hello", Timestamp: "1.000000"}}
+	c.forward(ev, nil, channel)
+	assert.Empty(t, h.sent)
+	c.config.ForwardOwn = true
+	c.forward(ev, nil, channel)
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "hello", h.sent[0].Message)
+	}
+}
+
+func TestForwardSlackbot(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.users = map[string]cachedUser{slackbotUser: {user: &slack.User{ID: slackbotUser, Name: "slackbot", RealName: "Slackbot"}, fetched: time.Now()}}
+	im := &slack.Channel{}
+	im.ID, im.IsIM, im.User = "D1", true, slackbotUser
+	general := &slack.Channel{}
+	general.ID, general.Name = "C1", "general"
+	post := func(channel *slack.Channel, text string) {
+		c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: channel.ID, User: slackbotUser, Text: text}}, nil, channel)
+	}
+
+	post(im, "reminder: standup")
+	post(general, "only visible to you")
+	assert.Len(t, h.sent, 2)
+
+	c.config.Slackbot = "direct"
+	post(im, "reminder: standup")
+	post(general, "only visible to you")
+	if assert.Len(t, h.sent, 3) {
+		assert.Equal(t, "reminder: standup", h.sent[2].Message)
+	}
+	assert.Equal(t, filteredBy("Slackbot response"), c.recent[len(c.recent)-1].disposition)
+
+	c.config.Slackbot = "none"
+	post(im, "reminder: standup")
+	assert.Len(t, h.sent, 3)
+	assert.Equal(t, filteredBy("Slackbot"), c.recent[len(c.recent)-1].disposition)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/nlopes/slack"
+)
+
+// sharedBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const sharedBuffer = 100
+
+// sharedConnections coordinates the plugin instances of Gotify users that
+// configured the same Slack token with ShareConnection: the first of them
+// to connect owns the connection and passes its events on to the others,
+// so the workspace is not connected to twice. The lookup caches and API
+// clients are not shared.
+var sharedConnections = struct {
+	sync.Mutex
+	owners map[string]*sharedConnection
+}{owners: make(map[string]*sharedConnection)}
+
+// sharedConnection is a connection owned by one instance.
+type sharedConnection struct {
+	owner       *Plugin
+	subscribers map[*Plugin]chan slack.RTMEvent
+}
+
+// sharedGoneEvent tells a subscriber that the connection it shared has
+// ended, so that it reconnects and possibly takes over.
+type sharedGoneEvent struct{}
+
+// tokenKey identifies a token without keeping it around.
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// shareConnection coordinates c's connection with the other instances using
+// token. If one of them owns a connection already, its events are returned
+// and c must not connect itself. Otherwise c becomes the owner and has to
+// pass its events through conn.forward. Either ends when stop is closed.
+func (c *Plugin) shareConnection(token string, stop chan struct{}) (events <-chan slack.RTMEvent, conn *sharedConnection) {
+	key := tokenKey(token)
+	sharedConnections.Lock()
+	defer sharedConnections.Unlock()
+	if owner, ok := sharedConnections.owners[key]; ok && owner.owner != c {
+		sub := make(chan slack.RTMEvent, sharedBuffer)
+		owner.subscribers[c] = sub
+		go func() {
+			<-stop
+			sharedConnections.Lock()
+			delete(owner.subscribers, c)
+			sharedConnections.Unlock()
+		}()
+		return relay(sub, stop), nil
+	}
+	conn = &sharedConnection{owner: c, subscribers: make(map[*Plugin]chan slack.RTMEvent)}
+	sharedConnections.owners[key] = conn
+	go func() {
+		<-stop
+		sharedConnections.Lock()
+		defer sharedConnections.Unlock()
+		if sharedConnections.owners[key] == conn {
+			delete(sharedConnections.owners, key)
+		}
+		for _, sub := range conn.subscribers {
+			close(sub)
+		}
+		conn.subscribers = nil
+	}()
+	return nil, conn
+}
+
+// relay passes the events of a subscription on until stop is closed and
+// reports the end of the shared connection with a sharedGoneEvent.
+func relay(sub <-chan slack.RTMEvent, stop chan struct{}) <-chan slack.RTMEvent {
+	out := make(chan slack.RTMEvent)
+	go func() {
+		for {
+			ev, ok := <-sub
+			if !ok {
+				ev = slack.RTMEvent{Type: "shared_gone", Data: &sharedGoneEvent{}}
+			}
+			select {
+			case <-stop:
+				return
+			case out <- ev:
+			}
+			if !ok {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// forward passes the events received from in on to the owner through the
+// returned channel and to the subscribers until stop is closed. Subscribers
+// that fall too far behind miss events rather than holding up the owner;
+// these are counted like events dropped from their queue.
+func (conn *sharedConnection) forward(in <-chan slack.RTMEvent, stop chan struct{}) <-chan slack.RTMEvent {
+	out := make(chan slack.RTMEvent)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case ev, ok := <-in:
+				if !ok {
+					return
+				}
+				sharedConnections.Lock()
+				for p, sub := range conn.subscribers {
+					select {
+					case sub <- ev:
+					default:
+						p.countDropped(ev)
+					}
+				}
+				sharedConnections.Unlock()
+				select {
+				case <-stop:
+					return
+				case out <- ev:
+				}
+			}
+		}
+	}()
+	return out
+}
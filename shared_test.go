@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, events <-chan slack.RTMEvent) slack.RTMEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	return slack.RTMEvent{}
+}
+
+func TestShareConnection(t *testing.T) {
+	owner, other := &Plugin{}, &Plugin{}
+	stopOwner, stopOther := make(chan struct{}), make(chan struct{})
+	defer close(stopOther)
+
+	events, conn := owner.shareConnection("xoxp-shared", stopOwner)
+	assert.Nil(t, events)
+	assert.NotNil(t, conn)
+	subscribed, notOwner := other.shareConnection("xoxp-shared", stopOther)
+	assert.NotNil(t, subscribed)
+	assert.Nil(t, notOwner)
+
+	in := make(chan slack.RTMEvent)
+	out := conn.forward(in, stopOwner)
+	msg := slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{}}
+	in <- msg
+	assert.Equal(t, "message", receive(t, out).Type)
+	assert.Equal(t, "message", receive(t, subscribed).Type)
+
+	close(stopOwner)
+	assert.IsType(t, &sharedGoneEvent{}, receive(t, subscribed).Data)
+
+	// With the owner gone, the next instance to connect takes over.
+	stop := make(chan struct{})
+	defer close(stop)
+	events, conn = other.shareConnection("xoxp-shared", stop)
+	assert.Nil(t, events)
+	assert.NotNil(t, conn)
+}
+
+func TestSharedDrops(t *testing.T) {
+	slow := &Plugin{}
+	conn := &sharedConnection{owner: &Plugin{}, subscribers: map[*Plugin]chan slack.RTMEvent{slow: make(chan slack.RTMEvent, 1)}}
+	in, stop := make(chan slack.RTMEvent), make(chan struct{})
+	defer close(stop)
+	out := conn.forward(in, stop)
+
+	// A subscriber falling behind misses events, but they are counted.
+	for i := 0; i < 3; i++ {
+		in <- slack.RTMEvent{Type: "message", Data: &slack.MessageEvent{}}
+		<-out
+	}
+	slow.mu.Lock()
+	defer slow.mu.Unlock()
+	assert.Equal(t, 2, slow.queueStats.dropped)
+	assert.Equal(t, 2, slow.overflowed)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultAPIURL = "https://slack.com/api/"
+
+// apiURL returns the base URL of the Slack Web API.
+func (conf *Config) apiURL() string {
+	if conf.APIURL == "" {
+		return defaultAPIURL
+	}
+	if !strings.HasSuffix(conf.APIURL, "/") {
+		return conf.APIURL + "/"
+	}
+	return conf.APIURL
+}
+
+// call invokes a Slack Web API method the slack library does not cover and
+// decodes the response into out.
+func (conf *Config) call(method string, values url.Values, out interface{}) error {
+	return conf.post(method, values, conf.SlackToken, out)
+}
+
+// call invokes a Slack Web API method with the current config, counting
+// the call against the API budget.
+func (c *Plugin) call(method string, values url.Values, out interface{}) error {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	c.countCall(method)
+	return config.call(method, values, out)
+}
+
+// post invokes a Slack Web API method authorized with token, if not empty.
+func (conf *Config) post(method string, values url.Values, token string, out interface{}) error {
+	hc, err := conf.httpClient()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", conf.apiURL()+method, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var status struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return err
+	}
+	if !status.Ok {
+		return errors.New(status.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/gotify/plugin-api"
+)
+
+// storedState is the state the plugin persists in gotify's plugin storage.
+type storedState struct {
+	Token  *rotatedToken   `json:"token,omitempty"`
+	Import *importedConfig `json:"import,omitempty"`
+	// Offsets holds the timestamp of the last message handled by channel ID.
+	Offsets map[string]string `json:"offsets,omitempty"`
+	// Cache holds the lookup caches as of the last shutdown.
+	Cache *storedCache `json:"cache,omitempty"`
+	// Quiet holds the quiet hours set with the quiet command.
+	Quiet *quietOverride `json:"quiet,omitempty"`
+	// ActionKey signs the action links of notifications, hex encoded.
+	ActionKey string `json:"actionKey,omitempty"`
+}
+
+// SetStorageHandler implements plugin.Storager.
+func (c *Plugin) SetStorageHandler(h plugin.StorageHandler) {
+	c.stateMu.Lock()
+	c.storage = h
+	c.stateMu.Unlock()
+}
+
+// loadState reads the persisted state. It is empty if nothing has been stored yet.
+func (c *Plugin) loadState() (storedState, error) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.readState()
+}
+
+// updateState applies update to the persisted state and stores the result.
+func (c *Plugin) updateState(update func(*storedState)) error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	state, err := c.readState()
+	if err != nil {
+		return err
+	}
+	update(&state)
+	if c.storage == nil {
+		return nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.storage.Save(b)
+}
+
+// readState reads the persisted state. c.stateMu must be held.
+func (c *Plugin) readState() (storedState, error) {
+	var state storedState
+	if c.storage == nil {
+		return state, nil
+	}
+	b, err := c.storage.Load()
+	if err != nil || len(b) == 0 {
+		return state, err
+	}
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/url"
+)
+
+// teamName returns the name of the workspace with the given ID. Org-wide
+// tokens receive events of several workspaces, so the workspace is looked
+// up per message rather than taken from auth.test. Names are cached; if a
+// lookup fails, the token's workspace is named.
+func (c *Plugin) teamName(id string) string {
+	homeID, home := c.homeTeam()
+	if id == "" || id == homeID {
+		return home
+	}
+	c.mu.Lock()
+	name, ok := c.teamNames[id]
+	c.mu.Unlock()
+	if ok {
+		return name
+	}
+	var resp struct {
+		Team struct {
+			Name string `json:"name"`
+		} `json:"team"`
+	}
+	if err := c.call("team.info", url.Values{"team": {id}}, &resp); err != nil {
+		c.logln(err)
+		if !isRestrictedError(err) {
+			return home
+		}
+	}
+	if resp.Team.Name == "" {
+		// Workspaces the token may not see, e.g. of external members of
+		// shared channels, are named like the token's.
+		resp.Team.Name = home
+	}
+	c.mu.Lock()
+	if c.teamNames == nil {
+		c.teamNames = make(map[string]string)
+	}
+	c.teamNames[id] = resp.Team.Name
+	c.mu.Unlock()
+	return resp.Team.Name
+}
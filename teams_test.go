@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamName(t *testing.T) {
+	lookups := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		assert.Equal(t, "/team.info", r.URL.Path)
+		r.ParseForm()
+		if r.Form.Get("team") == "T2" {
+			fmt.Fprint(w, `{"ok":true,"team":{"id":"T2","name":"Acme EU"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":false,"error":"team_access_not_granted"}`)
+	}))
+	defer srv.Close()
+
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config), team: "Acme", teamID: "T1"}
+	c.config.APIURL = srv.URL
+	assert.Equal(t, "Acme", c.teamName(""))
+	assert.Equal(t, "Acme", c.teamName("T1"))
+	assert.Equal(t, "Acme EU", c.teamName("T2"))
+	assert.Equal(t, "Acme EU", c.teamName("T2"))
+	assert.Equal(t, "Acme", c.teamName("T3"))
+	assert.Equal(t, "Acme", c.teamName("T3"))
+	assert.Equal(t, 2, lookups)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// templateData is available to title and body templates, e.g.
+// "{{.User}}: {{.Text}}" or "{{.Text}}\n{{.Permalink}}".
+type templateData struct {
+	Team     string
+	Channel  string
+	User     string
+	Title    string
+	Text     string
+	Priority int
+
+	permalink func() string
+}
+
+// Permalink returns the link to the message in Slack. It is only looked up
+// if a template uses it.
+func (d templateData) Permalink() string {
+	if d.permalink == nil {
+		return ""
+	}
+	return d.permalink()
+}
+
+// parseTemplate parses a title or body template.
+func parseTemplate(s string) (*template.Template, error) {
+	return template.New("").Option("missingkey=zero").Parse(s)
+}
+
+// validateTemplates checks whether the templates of a channel config parse.
+func validateTemplates(title, body string) error {
+	for _, s := range []string{title, body} {
+		if _, err := parseTemplate(s); err != nil {
+			return fmt.Errorf("invalid template %q: %v", s, err)
+		}
+	}
+	return nil
+}
+
+// renderTemplate executes the template s, returning an empty string for an empty template.
+func renderTemplate(s string, data templateData) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	t, err := parseTemplate(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// applyTemplates replaces the title and body of msg as configured for its
+// channel. Rendering errors leave the default format in place.
+func (c *Plugin) applyTemplates(channel *slack.Channel, msg *plugin.Message, data templateData) {
+	cc := c.channelConfig(channel)
+	if cc == nil || (cc.TitleTemplate == "" && cc.BodyTemplate == "") {
+		return
+	}
+	data.Title = msg.Title
+	data.Priority = msg.Priority
+	title, err := renderTemplate(cc.TitleTemplate, data)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	body, err := renderTemplate(cc.BodyTemplate, data)
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	if title != "" {
+		msg.Title = title
+	}
+	if body != "" {
+		msg.Message = body
+	}
+}
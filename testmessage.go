@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+)
+
+// testMessageText exercises the formatting options.
+const testMessageText = "This is a *test message* from the Slack plugin with a <https://gotify.net|link> &amp; an emoji :tada:\nIts second line."
+
+// sendTestMessage passes a synthetic message through the same filters and
+// formatting as messages from Slack, so the configuration can be checked
+// without waiting for real traffic.
+func (c *Plugin) sendTestMessage() {
+	team, _ := c.homeTeam()
+	channel := &slack.Channel{}
+	channel.ID = "test"
+	channel.Name = "gotify-test"
+	ev := &slack.MessageEvent{Msg: slack.Msg{
+		Type:      "message",
+		SubType:   "bot_message",
+		Channel:   channel.ID,
+		BotID:     "test",
+		Username:  "Gotify",
+		Text:      testMessageText,
+		Team:      team,
+		Timestamp: "0.000000",
+	}}
+	c.forward(ev, nil, channel)
+}
+
+// handleTestMessage sends a test message.
+func (c *Plugin) handleTestMessage(ctx *gin.Context) {
+	c.mu.Lock()
+	configured := c.config != nil
+	c.mu.Unlock()
+	if !configured {
+		ctx.String(http.StatusServiceUnavailable, "The plugin is not configured.")
+		return
+	}
+	c.sendTestMessage()
+	ctx.String(http.StatusOK, tr(c.locale(), "A test message was passed through the filters and formatting; the plugin page lists what happened to it."))
+}
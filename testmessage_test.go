@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendTestMessage(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, team: "Acme", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.sendTestMessage()
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack | Acme | gotify-test | Gotify", h.sent[0].Title)
+		assert.Equal(t, "This is a test message from the Slack plugin with a link (https://gotify.net) & an emoji\nIts second line.", h.sent[0].Message)
+		assert.Equal(t, 5, h.sent[0].Priority)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.DryRun = true
+	c.sendTestMessage()
+	assert.Empty(t, h.sent)
+	if assert.Len(t, c.recent, 1) {
+		assert.Equal(t, "would forward (priority 5)", c.recent[0].disposition)
+	}
+}
+
+func TestChannelTemplates(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.config.CollapseLines = true
+	c.config.Channels = []ChannelConfig{{Match: []string{"#gotify-test"}, TitleTemplate: "{{.User}} in #{{.Channel}}: {{.Text}}", BodyTemplate: "priority {{.Priority}}"}}
+	c.sendTestMessage()
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Gotify in #gotify-test: This is a test message from the Slack plugin with a link (https://gotify.net) & an emoji ⏎ Its second line.", h.sent[0].Title)
+		assert.Equal(t, "priority 5", h.sent[0].Message)
+	}
+	assert.Error(t, ChannelConfig{TitleTemplate: "{{.User"}.Validate())
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+const (
+	// threadTopicLength is the maximum length of a thread's topic in roll-ups.
+	threadTopicLength = 40
+	// threadIdle is how long a summarized thread is remembered without replies.
+	threadIdle = 24 * time.Hour
+	// followIdle is how long a followed thread is remembered after the
+	// user last wrote in it.
+	followIdle = 7 * 24 * time.Hour
+)
+
+// followThread follows the thread of msg, a message of the user: the thread
+// replied to or, as it may start one, the message itself.
+func (c *Plugin) followThread(msg *slack.Msg, now time.Time) {
+	ts := msg.ThreadTimestamp
+	if ts == "" {
+		ts = msg.Timestamp
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.config.FollowThreads || msg.Channel == "" || ts == "" {
+		return
+	}
+	for key, active := range c.followed {
+		if now.Sub(active) >= followIdle {
+			delete(c.followed, key)
+		}
+	}
+	if c.followed == nil {
+		c.followed = make(map[string]time.Time)
+	}
+	c.followed[msg.Channel+"/"+ts] = now
+}
+
+// following reports whether msg is a reply in a thread the user follows.
+func (c *Plugin) following(msg *slack.Msg) bool {
+	ts := msg.ThreadTimestamp
+	if ts == "" || ts == msg.Timestamp {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.followed[msg.Channel+"/"+ts]
+	return ok && c.config.FollowThreads
+}
+
+// summarizedThread collects the replies of a thread for its next roll-up.
+type summarizedThread struct {
+	channel *slack.Channel
+	conv    string
+	title   string
+	topic   string
+	replies int
+	// rolledUp is when the last roll-up was sent or the thread started.
+	rolledUp time.Time
+	active   time.Time
+}
+
+// summarizeThread counts a reply towards its thread's roll-up instead of
+// forwarding it if thread summarization is enabled. The first reply
+// forwarded is the start of the thread, replies mentioning the user always
+// break through. It reports whether the reply has been absorbed.
+func (c *Plugin) summarizeThread(msg *slack.Msg, channel *slack.Channel, conv, title, text string, now time.Time) bool {
+	c.mu.Lock()
+	enabled := c.config.ThreadSummary > 0
+	c.mu.Unlock()
+	ts := msg.ThreadTimestamp
+	if !enabled || ts == "" || ts == msg.Timestamp || c.mentionsMe(text) {
+		return false
+	}
+	key := msg.Channel + "/" + ts
+	c.mu.Lock()
+	t, ok := c.threads[key]
+	if ok {
+		t.replies++
+		t.active = now
+		t.title = title
+	}
+	c.mu.Unlock()
+	if ok {
+		return true
+	}
+	t = &summarizedThread{channel: channel, conv: conv, title: title, topic: c.threadTopic(msg.Channel, ts), rolledUp: now, active: now}
+	c.mu.Lock()
+	if c.threads == nil {
+		c.threads = make(map[string]*summarizedThread)
+	}
+	c.threads[key] = t
+	c.mu.Unlock()
+	return false
+}
+
+// threadTopic returns the shortened text of a thread's parent message.
+func (c *Plugin) threadTopic(channel, ts string) string {
+	if api := c.slackAPI(); api != nil {
+		msgs, _, _, err := api.GetConversationReplies(&slack.GetConversationRepliesParameters{ChannelID: channel, Timestamp: ts, Limit: 1})
+		if err != nil {
+			c.logln(err)
+		} else if len(msgs) != 0 && msgs[0].Text != "" {
+			return shorten(collapseLines(unescape(plainText(msgs[0].Text)), 1, true), threadTopicLength)
+		}
+	}
+	return ts
+}
+
+// flushThreads sends the roll-ups that are due and forgets idle threads.
+func (c *Plugin) flushThreads(now time.Time) {
+	c.mu.Lock()
+	interval := c.config.ThreadSummary
+	var due []summarizedThread
+	for key, t := range c.threads {
+		if t.replies != 0 && now.Sub(t.rolledUp) >= interval {
+			due = append(due, *t)
+			t.replies = 0
+			t.rolledUp = now
+		} else if t.replies == 0 && now.Sub(t.active) >= threadIdle {
+			delete(c.threads, key)
+		}
+	}
+	c.mu.Unlock()
+	locale := c.locale()
+	for _, t := range due {
+		text := fmt.Sprintf(tr(locale, "+%d replies in thread '%s'"), t.replies, t.topic)
+		c.deliver(t.channel, t.conv, plugin.Message{Title: t.title, Message: text, Priority: c.defaultPriority()})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeThread(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, uid: "U1", config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.ThreadSummary = 10 * time.Minute
+	channel := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}
+	reply := func(ts, text string) *slack.Msg {
+		return &slack.Msg{Channel: "C1", Timestamp: ts, ThreadTimestamp: "100.0", Text: text}
+	}
+	start := time.Now()
+	assert.False(t, c.summarizeThread(&slack.Msg{Channel: "C1", Timestamp: "100.0", ThreadTimestamp: "100.0"}, channel, "#ops", "Slack | ops", "deploy failed", start))
+	assert.False(t, c.summarizeThread(reply("101.0", "looking"), channel, "#ops", "Slack | ops", "looking", start))
+	assert.True(t, c.summarizeThread(reply("102.0", "same here"), channel, "#ops", "Slack | ops", "same here", start))
+	assert.True(t, c.summarizeThread(reply("103.0", "+1"), channel, "#ops", "Slack | ops", "+1", start))
+	assert.False(t, c.summarizeThread(reply("104.0", "<@U1> ping"), channel, "#ops", "Slack | ops", "<@U1> ping", start))
+
+	c.flushThreads(start.Add(time.Minute))
+	assert.Empty(t, h.sent)
+	c.flushThreads(start.Add(10 * time.Minute))
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "+2 replies in thread '100.0'", h.sent[0].Message)
+	}
+	c.flushThreads(start.Add(20 * time.Minute))
+	assert.Len(t, h.sent, 1)
+}
+
+func TestFollowThreads(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	now := time.Now()
+	c.followThread(&slack.Msg{Channel: "C1", Timestamp: "100.000200", ThreadTimestamp: "100.000100"}, now)
+	c.followThread(&slack.Msg{Channel: "C1", Timestamp: "200.000100"}, now)
+	assert.True(t, c.following(&slack.Msg{Channel: "C1", Timestamp: "100.000300", ThreadTimestamp: "100.000100"}))
+	assert.True(t, c.following(&slack.Msg{Channel: "C1", Timestamp: "200.000200", ThreadTimestamp: "200.000100"}))
+	assert.False(t, c.following(&slack.Msg{Channel: "C1", Timestamp: "200.000100", ThreadTimestamp: "200.000100"}))
+	assert.False(t, c.following(&slack.Msg{Channel: "C2", Timestamp: "100.000300", ThreadTimestamp: "100.000100"}))
+
+	c.followThread(&slack.Msg{Channel: "C3", Timestamp: "300.000100"}, now.Add(followIdle))
+	assert.False(t, c.following(&slack.Msg{Channel: "C1", Timestamp: "100.000300", ThreadTimestamp: "100.000100"}))
+	c.config.FollowThreads = false
+	assert.False(t, c.following(&slack.Msg{Channel: "C3", Timestamp: "300.000200", ThreadTimestamp: "300.000100"}))
+}
+
+func TestThreadPermalink(t *testing.T) {
+	var asked []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		asked = append(asked, r.Form.Get("message_ts"))
+		fmt.Fprintf(w, `{"ok":true,"channel":"C1","permalink":"https://acme.slack.com/archives/C1/p%s?thread_ts=1.000000"}`, strings.Replace(r.Form.Get("message_ts"), ".", "", 1))
+	}))
+	defer srv.Close()
+
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.config.Format = "plain"
+	c.config.APIURL = srv.URL
+	c.api, _ = c.config.client()
+	c.users = map[string]cachedUser{"U2": {user: &slack.User{ID: "U2", RealName: "Alice"}, fetched: time.Now()}}
+	channel := &slack.Channel{GroupConversation: slack.GroupConversation{Name: "ops", Conversation: slack.Conversation{ID: "C1"}}}
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: "deploy failed", Timestamp: "1.000000", ThreadTimestamp: "1.000000"}}, nil, channel)
+	c.forward(&slack.MessageEvent{Msg: slack.Msg{Type: "message", Channel: "C1", User: "U2", Text: "rolled back", Timestamp: "2.000000", ThreadTimestamp: "1.000000"}}, nil, channel)
+	assert.Equal(t, []string{"2.000000"}, asked)
+	if assert.Len(t, h.sent, 2) {
+		assert.NotContains(t, h.sent[0].Extras, "client::notification")
+		link := "https://acme.slack.com/archives/C1/p2000000?thread_ts=1.000000"
+		assert.Equal(t, "rolled back\nThread: "+link, h.sent[1].Message)
+		assert.Equal(t, map[string]string{"url": link}, h.sent[1].Extras["client::notification"].(map[string]interface{})["click"])
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+)
+
+// defaultTitleParts reproduces the classic "Slack | team | channel | user" title.
+var defaultTitleParts = []string{"slack", "team", "channel", "user"}
+
+// titleParts holds the values available to compose a notification title.
+type titleParts struct {
+	team    string
+	channel string
+	user    string
+}
+
+// compose joins the requested parts with sep, skipping parts without a value.
+// Known parts are "slack", "team", "channel", "#channel" and "user".
+func (p titleParts) compose(parts []string, sep string) string {
+	var values []string
+	for _, part := range parts {
+		var v string
+		switch part {
+		case "slack":
+			v = "Slack"
+		case "team":
+			v = p.team
+		case "channel":
+			v = p.channel
+		case "#channel":
+			if p.channel != "" {
+				v = "#" + p.channel
+			}
+		case "user":
+			v = p.user
+		}
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, sep)
+}
+
+// capTitle shortens title to at most max runes, if max is positive. The
+// parts separated by sep before the last two, usually the channel and the
+// sender, are dropped first, starting with "Slack" and the team, then the
+// middle of the remaining title is replaced by "…". Prefixes added with
+// prefixTitle fit already.
+func capTitle(title, sep string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(title) <= max {
+		return title
+	}
+	parts := strings.Split(title, sep)
+	for len(parts) > 2 && utf8.RuneCountInString(strings.Join(parts, sep)) > max {
+		parts = parts[1:]
+	}
+	r := []rune(strings.Join(parts, sep))
+	if len(r) <= max {
+		return string(r)
+	}
+	if max == 1 {
+		return "…"
+	}
+	head := (max - 1) / 2
+	tail := max - 1 - head
+	return string(r[:head]) + "…" + string(r[len(r)-tail:])
+}
+
+// prefixTitle prepends prefixes to title as parts of their own. The rest
+// of the title is capped to leave room for the prefixes, so that capping
+// the whole title does not drop them.
+func (c *Plugin) prefixTitle(title string, prefixes ...string) string {
+	if len(prefixes) == 0 {
+		return title
+	}
+	c.mu.Lock()
+	sep, max := c.config.TitleSeparator, c.config.MaxTitleLength
+	c.mu.Unlock()
+	if sep == "" {
+		sep = " | "
+	}
+	prefix := strings.Join(prefixes, sep)
+	if max > 0 {
+		room := max - utf8.RuneCountInString(prefix+sep)
+		if room < 1 {
+			room = 1
+		}
+		title = capTitle(title, sep, room)
+	}
+	return prefix + sep + title
+}
+
+// statusTitle returns the title of the plugin's own notifications about
+// the given subject, e.g. a lost connection, composed like message titles.
+func (c *Plugin) statusTitle(subject string) string {
+	return c.title(titleParts{team: c.teamName(""), channel: subject})
+}
+
+// validTitlePart reports whether part can be used in the title.
+func validTitlePart(part string) bool {
+	switch part {
+	case "slack", "team", "channel", "#channel", "user":
+		return true
+	}
+	return false
+}
+
+// TitleRule prefixes the title of messages whose text matches Pattern, a
+// regular expression, e.g. "🔥 INCIDENT" for "(?i)incident|outage".
+// Channels optionally restricts the rule to channels as in ChannelConfig.
+type TitleRule struct {
+	Pattern  string
+	Prefix   string
+	Channels []string
+}
+
+// Validate checks whether the pattern of the rule compiles.
+func (r TitleRule) Validate() error {
+	if err := validateChannelPatterns(r.Channels); err != nil {
+		return err
+	}
+	if _, err := cachedRegexp(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", r.Pattern, err)
+	}
+	return nil
+}
+
+// titlePrefix returns the prefix of the first title rule matching the message.
+func (c *Plugin) titlePrefix(channel *slack.Channel, text string) string {
+	c.mu.Lock()
+	rules := c.config.TitleRules
+	c.mu.Unlock()
+	for _, r := range rules {
+		if len(r.Channels) != 0 && !matchChannel(r.Channels, channel) {
+			continue
+		}
+		if re, err := cachedRegexp(r.Pattern); err == nil && re.MatchString(text) {
+			return r.Prefix
+		}
+	}
+	return ""
+}
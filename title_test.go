@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitleCompose(t *testing.T) {
+	p := titleParts{team: "Acme", channel: "general", user: "Alice"}
+	assert.Equal(t, "Slack | Acme | general | Alice", p.compose(defaultTitleParts, " | "))
+	assert.Equal(t, "Alice @ #general", p.compose([]string{"user", "#channel"}, " @ "))
+	dm := titleParts{team: "Acme", user: "Alice"}
+	assert.Equal(t, "Slack | Acme | Alice", dm.compose(defaultTitleParts, " | "))
+}
+
+func TestTitleOmit(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	p := titleParts{team: "Acme", channel: "general", user: "Alice"}
+	c.config.OmitSlack = true
+	assert.Equal(t, "Acme | general | Alice", c.title(p))
+	c.config.OmitTeam = true
+	assert.Equal(t, "general | Alice", c.title(p))
+	c.config.OmitSlack = false
+	assert.Equal(t, "Slack | general | Alice", c.title(p))
+}
+
+func TestCapTitle(t *testing.T) {
+	title := "Slack | Acme Corporation | general | Alice"
+	assert.Equal(t, title, capTitle(title, " | ", 0))
+	assert.Equal(t, title, capTitle(title, " | ", 60))
+	assert.Equal(t, "Acme Corporation | general | Alice", capTitle(title, " | ", 40))
+	assert.Equal(t, "general | Alice", capTitle(title, " | ", 30))
+	assert.Equal(t, "gener… Alice", capTitle(title, " | ", 12))
+	assert.Equal(t, "…", capTitle(title, " | ", 1))
+}
+
+func TestPrefixTitle(t *testing.T) {
+	c := &Plugin{config: (&Plugin{}).DefaultConfig().(*Config)}
+	title := "Slack | Acme Corporation | general | Alice"
+	assert.Equal(t, title, c.prefixTitle(title))
+	assert.Equal(t, "🔥 INCIDENT | "+title, c.prefixTitle(title, "🔥 INCIDENT"))
+	c.config.MaxTitleLength = 30
+	prefixed := c.prefixTitle(title, "🔥 INCIDENT")
+	assert.Equal(t, "🔥 INCIDENT | general | Alice", prefixed)
+	assert.Equal(t, prefixed, capTitle(prefixed, " | ", 30))
+	prefixed = c.prefixTitle(title, "Celebration", "🔥 INCIDENT")
+	assert.Equal(t, "Celebration | 🔥 INCIDENT | g…e", prefixed)
+	assert.Equal(t, prefixed, capTitle(prefixed, " | ", 30))
+}
+
+func TestTitlePrefix(t *testing.T) {
+	c := &Plugin{config: &Config{TitleRules: []TitleRule{
+		{Pattern: "(?i)deploy", Prefix: "🚀", Channels: []string{"#releases"}},
+		{Pattern: "(?i)incident|outage", Prefix: "🔥 INCIDENT"},
+	}}}
+	general := &slack.Channel{}
+	general.Name = "general"
+	releases := &slack.Channel{}
+	releases.Name = "releases"
+	assert.Equal(t, "🔥 INCIDENT", c.titlePrefix(general, "Major OUTAGE in eu-west"))
+	assert.Equal(t, "", c.titlePrefix(general, "deploying v2"))
+	assert.Equal(t, "🚀", c.titlePrefix(releases, "Deploying v2"))
+	assert.Error(t, TitleRule{Pattern: "("}.Validate())
+}
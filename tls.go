@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// tlsConfig builds the TLS configuration for connections to Slack,
+// nil if the defaults are to be used.
+func (conf *Config) tlsConfig() (*tls.Config, error) {
+	if conf.CACertFile == "" && !conf.InsecureSkipVerify {
+		return nil, nil
+	}
+	tc := &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify}
+	if conf.CACertFile != "" {
+		pem, err := ioutil.ReadFile(conf.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", conf.CACertFile)
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+// tlsSettings identifies the TLS settings of a config. The modification
+// time of the CA bundle is part of it, so that a replaced bundle is read again.
+type tlsSettings struct {
+	caCertFile string
+	modified   time.Time
+	insecure   bool
+}
+
+// httpClients caches the HTTP clients by TLS settings, so that calls share
+// their connections instead of each setting up a transport of its own.
+var httpClients sync.Map
+
+// httpClient returns the HTTP client for the Slack Web API.
+func (conf *Config) httpClient() (*http.Client, error) {
+	if conf.CACertFile == "" && !conf.InsecureSkipVerify {
+		return http.DefaultClient, nil
+	}
+	key := tlsSettings{caCertFile: conf.CACertFile, insecure: conf.InsecureSkipVerify}
+	if conf.CACertFile != "" {
+		fi, err := os.Stat(conf.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		key.modified = fi.ModTime()
+	}
+	if hc, ok := httpClients.Load(key); ok {
+		return hc.(*http.Client), nil
+	}
+	tc, err := conf.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	hc, _ := httpClients.LoadOrStore(key, &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tc,
+			TLSHandshakeTimeout: 10 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	})
+	return hc.(*http.Client), nil
+}
+
+// dialer returns the websocket dialer for the RTM connection.
+func (conf *Config) dialer() (*websocket.Dialer, error) {
+	tc, err := conf.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tc == nil {
+		return websocket.DefaultDialer, nil
+	}
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		TLSClientConfig:  tc,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "gotify-slack")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	ca := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+	empty := filepath.Join(dir, "empty.pem")
+	assert.NoError(t, ioutil.WriteFile(empty, nil, 0600))
+
+	get := func(conf *Config) error {
+		hc, err := conf.httpClient()
+		if err != nil {
+			return err
+		}
+		resp, err := hc.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	conf := &Config{}
+	tc, err := conf.tlsConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tc)
+	assert.Error(t, get(conf))
+	assert.NoError(t, get(&Config{InsecureSkipVerify: true}))
+	assert.NoError(t, get(&Config{CACertFile: ca}))
+	assert.Error(t, get(&Config{CACertFile: empty}))
+	assert.Error(t, get(&Config{CACertFile: filepath.Join(dir, "missing.pem")}))
+
+	// Clients are reused until the CA bundle changes.
+	hc, err := (&Config{CACertFile: ca}).httpClient()
+	assert.NoError(t, err)
+	same, err := (&Config{CACertFile: ca}).httpClient()
+	assert.NoError(t, err)
+	assert.True(t, hc == same)
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(ca, later, later))
+	changed, err := (&Config{CACertFile: ca}).httpClient()
+	assert.NoError(t, err)
+	assert.False(t, hc == changed)
+}
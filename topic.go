@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nlopes/slack"
+)
+
+// topicText describes a change of a channel's topic or purpose by sender,
+// given the channel_topic or channel_purpose message announcing it.
+// ok is false for other messages.
+func (c *Plugin) topicText(msg *slack.Msg, sender, channel string) (text string, ok bool) {
+	l := c.locale()
+	switch msg.SubType {
+	case "channel_topic", "group_topic":
+		return fmt.Sprintf(tr(l, "%s changed #%s topic to: %s"), sender, channel, msg.Topic), true
+	case "channel_purpose", "group_purpose":
+		return fmt.Sprintf(tr(l, "%s changed #%s purpose to: %s"), sender, channel, msg.Purpose), true
+	}
+	return "", false
+}
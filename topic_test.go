@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicText(t *testing.T) {
+	c := &Plugin{config: &Config{}}
+	text, ok := c.topicText(&slack.Msg{SubType: "channel_topic", Topic: "Deploys only"}, "Alice", "ops")
+	assert.True(t, ok)
+	assert.Equal(t, "Alice changed #ops topic to: Deploys only", text)
+	text, _ = c.topicText(&slack.Msg{SubType: "group_purpose", Purpose: "Incidents"}, "Alice", "ops")
+	assert.Equal(t, "Alice changed #ops purpose to: Incidents", text)
+	_, ok = c.topicText(&slack.Msg{Text: "hi"}, "Alice", "ops")
+	assert.False(t, ok)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gotify/plugin-api"
+	"github.com/nlopes/slack"
+)
+
+// minUnreadInterval bounds UnreadInterval, as counting takes API calls for
+// every conversation.
+const minUnreadInterval = 5 * time.Minute
+
+// unreadCounts are the user's unread messages and mentions across all conversations.
+type unreadCounts struct {
+	unread   int
+	mentions int
+}
+
+// unreadTicker returns a channel ticking at UnreadInterval and a function
+// stopping it. Without an interval the channel never ticks.
+func (c *Plugin) unreadTicker() (<-chan time.Time, func()) {
+	c.mu.Lock()
+	interval := c.config.UnreadInterval
+	c.mu.Unlock()
+	if interval <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTicker(interval)
+	return t.C, t.Stop
+}
+
+// countUnread adds up the unread messages of the conversations the user is
+// a member of. Unread messages count as mentions if they mention the user
+// or are direct messages.
+func (c *Plugin) countUnread() (unreadCounts, error) {
+	var counts unreadCounts
+	channels, err := c.memberConversations()
+	if err != nil {
+		return counts, err
+	}
+	for _, ch := range channels {
+		info, err := c.slackAPI().GetConversationInfo(ch.ID, false)
+		if err != nil {
+			return counts, err
+		}
+		if info.UnreadCountDisplay == 0 {
+			continue
+		}
+		counts.unread += info.UnreadCountDisplay
+		if info.IsIM || info.IsMpIM {
+			counts.mentions += info.UnreadCountDisplay
+			continue
+		}
+		history, err := c.slackAPI().GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: ch.ID, Oldest: info.LastRead, Limit: 100})
+		if err != nil {
+			return counts, err
+		}
+		for _, m := range history.Messages {
+			if c.mentionsMe(m.Text) {
+				counts.mentions++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// syncUnread counts the unread messages and notifies about changed counts.
+func (c *Plugin) syncUnread() {
+	c.mu.Lock()
+	if c.unreadSyncing {
+		c.mu.Unlock()
+		return
+	}
+	c.unreadSyncing = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.unreadSyncing = false
+		c.mu.Unlock()
+	}()
+	counts, err := c.countUnread()
+	if err != nil {
+		c.logln(err)
+		return
+	}
+	c.notifyUnread(counts)
+}
+
+// notifyUnread sends a badge-like notification with the counts if they
+// differ from those last sent.
+func (c *Plugin) notifyUnread(counts unreadCounts) {
+	c.mu.Lock()
+	if counts == c.unread {
+		c.mu.Unlock()
+		return
+	}
+	c.unread = counts
+	l, priority := c.config.Locale, c.config.UnreadPriority
+	c.mu.Unlock()
+	c.send(plugin.Message{
+		Title:    c.statusTitle(tr(l, "Unread")),
+		Message:  fmt.Sprintf(tr(l, "Slack: %d unread, %d mentions"), counts.unread, counts.mentions),
+		Priority: priority,
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyUnread(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	c.notifyUnread(unreadCounts{})
+	assert.Empty(t, h.sent)
+	c.notifyUnread(unreadCounts{unread: 14, mentions: 3})
+	c.notifyUnread(unreadCounts{unread: 14, mentions: 3})
+	if assert.Len(t, h.sent, 1) {
+		assert.Equal(t, "Slack: 14 unread, 3 mentions", h.sent[0].Message)
+		assert.Equal(t, 1, h.sent[0].Priority)
+	}
+	c.notifyUnread(unreadCounts{})
+	assert.Len(t, h.sent, 2)
+}
+
+func TestSyncUnreadOnce(t *testing.T) {
+	h := &fakeHandler{}
+	c := &Plugin{msgHandler: h, config: (&Plugin{}).DefaultConfig().(*Config)}
+	// A count still running is not overlapped; c.api is not even touched.
+	c.unreadSyncing = true
+	c.syncUnread()
+	assert.True(t, c.unreadSyncing)
+	assert.Empty(t, h.sent)
+}
+
+func TestUnreadIntervalBound(t *testing.T) {
+	c := &Plugin{}
+	config := c.DefaultConfig().(*Config)
+	config.SlackToken = "xoxp-test"
+	config.UnreadInterval = time.Minute
+	assert.EqualError(t, c.applyConfig(config), "UnreadInterval must be at least 5m0s")
+}
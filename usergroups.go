@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/nlopes/slack"
+)
+
+// loadGroups looks up the user groups the user belongs to, whose
+// <!subteam^ID> mentions count as mentions of the user.
+func (c *Plugin) loadGroups() error {
+	groups, err := c.slackAPI().GetUserGroups(slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		return err
+	}
+	uid := c.userID()
+	mine := make(map[string]bool)
+	for _, g := range groups {
+		for _, u := range g.Users {
+			if u == uid {
+				mine[g.ID] = true
+				break
+			}
+		}
+	}
+	c.mu.Lock()
+	c.groups = mine
+	c.mu.Unlock()
+	return nil
+}
+
+// setGroup records that the user has been added to or removed from a user group.
+func (c *Plugin) setGroup(id string, member bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.groups == nil {
+		c.groups = make(map[string]bool)
+	}
+	if member {
+		c.groups[id] = true
+	} else {
+		delete(c.groups, id)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMentions(t *testing.T) {
+	c := &Plugin{uid: "U1", config: &Config{DefaultPriority: 5, MentionPriority: 7}}
+	c.setGroup("S1", true)
+	c.setGroup("S2", true)
+	c.setGroup("S2", false)
+	assert.True(t, c.mentionsMe("<!subteam^S1|@oncall> please check"))
+	assert.True(t, c.mentionsMe("<!subteam^S1> please check"))
+	assert.False(t, c.mentionsMe("<!subteam^S2|@design> please check"))
+	assert.Equal(t, 7, c.messagePriority("<!subteam^S1|@oncall> please check"))
+	assert.Equal(t, 5, c.messagePriority("no mention"))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+)
+
+const commandHelp = "Usage: `/gotify mute [duration]`, `/gotify unmute`, `/gotify quiet HH:MM-HH:MM|off`, `/gotify stats`"
+
+// RegisterWebhook implements plugin.Webhooker.
+func (c *Plugin) RegisterWebhook(basePath string, mux *gin.RouterGroup) {
+	c.basePath = basePath
+	mux.POST("/command", c.handleCommand)
+	mux.GET("/events", c.handleEvents)
+	mux.GET("/mute", c.handleMute)
+	mux.GET("/test", c.handleTestMessage)
+	mux.GET("/config", c.handleExportConfig)
+	mux.POST("/config", c.handleImportConfig)
+}
+
+// routeURL returns the URL of a webhook route.
+func (c *Plugin) routeURL(location *url.URL, route string) string {
+	if location == nil || c.basePath == "" {
+		return ""
+	}
+	u := url.URL{
+		Scheme: location.Scheme,
+		Host:   location.Host,
+		Path:   path.Join(c.basePath, route),
+	}
+	return u.String()
+}
+
+// commandURL returns the URL Slack has to send slash commands to.
+func (c *Plugin) commandURL(location *url.URL) string {
+	return c.routeURL(location, "command")
+}
+
+func (c *Plugin) handleCommand(ctx *gin.Context) {
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := c.verifyRequest(ctx.Request.Header, body); err != nil {
+		ctx.String(http.StatusUnauthorized, err.Error())
+		return
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	cmd, err := slack.SlashCommandParse(ctx.Request)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx.String(http.StatusOK, c.runCommand(cmd))
+}
+
+// adminSecretHeader carries AdminSecret in requests to protected routes.
+const adminSecretHeader = "X-Admin-Secret"
+
+// authorized checks that a request to a protected route carries the
+// AdminSecret, responding with an error if it does not.
+func (c *Plugin) authorized(ctx *gin.Context) bool {
+	c.mu.Lock()
+	secret := ""
+	if c.config != nil {
+		secret = c.config.AdminSecret
+	}
+	c.mu.Unlock()
+	if secret == "" {
+		ctx.String(http.StatusForbidden, "set AdminSecret to use this route")
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(ctx.GetHeader(adminSecretHeader)), []byte(secret)) != 1 {
+		ctx.String(http.StatusUnauthorized, "invalid admin secret")
+		return false
+	}
+	return true
+}
+
+// verifyRequest checks the Slack signature of a request using the configured signing secret.
+func (c *Plugin) verifyRequest(header http.Header, body []byte) error {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+	if config == nil || config.SigningSecret == "" {
+		return errors.New("slash commands are not configured")
+	}
+	sv, err := slack.NewSecretsVerifier(header, config.SigningSecret)
+	if err != nil {
+		return err
+	}
+	if _, err := sv.Write(body); err != nil {
+		return err
+	}
+	return sv.Ensure()
+}
+
+func (c *Plugin) runCommand(cmd slack.SlashCommand) string {
+	uid := c.userID()
+	if uid == "" {
+		return "The plugin is not running."
+	}
+	if cmd.UserID != uid {
+		return "Only the owner of this bridge can control it."
+	}
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 {
+		return commandHelp
+	}
+	switch args[0] {
+	case "mute":
+		var until time.Time
+		if len(args) > 1 {
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				return fmt.Sprintf("Invalid duration %q.", args[1])
+			}
+			until = time.Now().Add(d)
+		}
+		c.mu.Lock()
+		if c.muted == nil {
+			c.muted = make(map[string]time.Time)
+		}
+		c.muted[cmd.ChannelID] = until
+		c.mu.Unlock()
+		if until.IsZero() {
+			return "Muted this channel."
+		}
+		return "Muted this channel until " + until.Format("15:04") + "."
+	case "unmute":
+		c.mu.Lock()
+		delete(c.muted, cmd.ChannelID)
+		c.mu.Unlock()
+		return "Unmuted this channel."
+	case "quiet":
+		if len(args) < 2 {
+			return commandHelp
+		}
+		var w Window
+		if args[1] != "off" {
+			bounds := strings.SplitN(args[1], "-", 2)
+			if len(bounds) != 2 {
+				return commandHelp
+			}
+			w = Window{Start: bounds[0], End: bounds[1]}
+			if err := w.Validate(); err != nil {
+				return err.Error()
+			}
+		}
+		if err := c.setQuietHours(w); err != nil {
+			c.logln(err)
+		}
+		return "Quiet hours: " + w.String()
+	case "stats":
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return fmt.Sprintf("Since %s: %d forwarded, %d filtered, %d channels muted, quiet hours: %s",
+			c.stats.since.Format("2006-01-02 15:04"), c.stats.forwarded, c.stats.filtered, len(c.muted), c.quietHours())
+	}
+	return commandHelp
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	c := &Plugin{config: &Config{SigningSecret: "secret"}}
+	body := []byte("command=%2Fgotify&text=stats")
+	sign := func(secret string, ts time.Time) http.Header {
+		stamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + stamp + ":" + string(body)))
+		header := http.Header{}
+		header.Set("X-Slack-Request-Timestamp", stamp)
+		header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return header
+	}
+	missing := sign("secret", time.Now())
+	missing.Del("X-Slack-Signature")
+	for _, tt := range []struct {
+		name   string
+		header http.Header
+		valid  bool
+	}{
+		{"valid signature", sign("secret", time.Now()), true},
+		{"bad signature", sign("other", time.Now()), false},
+		{"stale timestamp", sign("secret", time.Now().Add(-10*time.Minute)), false},
+		{"missing header", missing, false},
+	} {
+		err := c.verifyRequest(tt.header, body)
+		if tt.valid {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.Error(t, err, tt.name)
+		}
+	}
+
+	c.config.SigningSecret = ""
+	assert.EqualError(t, c.verifyRequest(sign("secret", time.Now()), body), "slash commands are not configured")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+func init() {
+	botParsers = append(botParsers, botParser{
+		name:  "workflow",
+		bots:  []string{"workflow"},
+		parse: parseWorkflow,
+	})
+}
+
+// workflowLabelRe matches the bold label of a form field, optionally
+// followed by the value on the same line.
+var workflowLabelRe = regexp.MustCompile(`^\*([^*]+?):?\*:?(?:\s+(.+))?$`)
+
+// parseWorkflow formats a Workflow Builder form submission, whose blocks
+// list the fields as bold labels followed by their values, as
+// "field: value" lines titled with the workflow's name. Its kind is
+// "submission".
+func parseWorkflow(c *Plugin, msg *slack.Msg, blocks json.RawMessage) *parsedMessage {
+	text := renderBlocks(blocks)
+	if text == "" {
+		text = msg.Text
+	}
+	var intro, fields []string
+	value := -1
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := workflowLabelRe.FindStringSubmatch(line); m != nil {
+			fields = append(fields, strings.TrimSpace(m[1])+":")
+			value = len(fields) - 1
+			if m[2] != "" {
+				fields[value] += " " + m[2]
+			}
+			continue
+		}
+		if value < 0 {
+			intro = append(intro, line)
+		} else {
+			fields[value] += " " + line
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &parsedMessage{
+		kind:  "submission",
+		label: msg.Username,
+		text:  strings.Join(append(intro, fields...), "\n"),
+	}
+}
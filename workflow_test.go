@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	c := &Plugin{config: &Config{Parsers: map[string]ParserConfig{"workflow": {Bots: []string{"Time off request"}}}}}
+	bot := &sender{id: "B1", name: "Time off request", bot: true}
+	blocks := json.RawMessage(`[
+		{"type":"section","text":{"type":"mrkdwn","text":"A new request was submitted"}},
+		{"type":"section","text":{"type":"mrkdwn","text":"*Who*\n<@U2>"}},
+		{"type":"section","text":{"type":"mrkdwn","text":"*Dates*\nMay 6 - May 10"}},
+		{"type":"section","text":{"type":"mrkdwn","text":"*Reason:* vacation"}}
+	]`)
+	name, p, _ := c.parseBotMessage(bot, &slack.Msg{Username: "Time off request"}, blocks)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "workflow", name)
+		assert.Equal(t, "Time off request", p.label)
+		assert.Equal(t, "A new request was submitted\nWho: <@U2>\nDates: May 6 - May 10\nReason: vacation", p.text)
+	}
+	_, p, _ = c.parseBotMessage(bot, &slack.Msg{Text: "Nothing to see"}, nil)
+	assert.Nil(t, p)
+}